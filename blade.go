@@ -6,8 +6,10 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"slices"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
@@ -20,18 +22,32 @@ type Blade struct {
 	server       *http.Server
 	tlsCertFile  string
 	tlsKeyFile   string
-	router       *Router[Handler]
+	routing      atomic.Pointer[routing]
+	serving      atomic.Bool
+	mu           sync.RWMutex //Guards route changes
+	routes       []*Route
+	names        map[string]*Route
 	middleware   []Middleware //Global middleware
 	contextPool  sync.Pool
 	notFoundFn   func(*Context) //404
+	notAllowedFn func(*Context) //405
 	errorHandler func(*Context, error)
+	paramLimit   int
+	autoHead     bool
+	autoOptions  bool
+	precompress  bool
+	etagMode     ETagMode
+	compression  CompressionConfig
+	pathPolicy   PathPolicy
+	fixCase      bool
 }
 
 // New creates a new blade.
 func New() *Blade {
 	b := &Blade{
-		router:     &Router[Handler]{},
-		notFoundFn: nil,
+		notFoundFn:  nil,
+		paramLimit:  defaultParamLimit,
+		compression: defaultCompression,
 		errorHandler: func(c *Context, err error) {
 			Log().Error("Error in handler",
 				zap.Error(err),
@@ -39,6 +55,8 @@ func New() *Blade {
 		},
 	}
 
+	b.routing.Store(&routing{router: &Router[Handler]{}})
+
 	// Context pool
 	b.contextPool.New = func() any { return &Context{b: b} }
 
@@ -49,6 +67,18 @@ func (b *Blade) NotFoundFn(f func(*Context)) {
 	b.notFoundFn = f
 }
 
+// MethodNotAllowedFn sets the function called when the path exists
+// under other methods only, the Allow header is already set when it's called.
+func (b *Blade) MethodNotAllowedFn(f func(*Context)) {
+	b.notAllowedFn = f
+}
+
+// ParamLimit sets the maximum number of parameters of a route including the ones of its host,
+// registering a route with more panics. It defaults to 64.
+func (b *Blade) ParamLimit(n int) {
+	b.paramLimit = n
+}
+
 func (b *Blade) TlsCertFile(f string) {
 	b.tlsCertFile = f
 }
@@ -58,50 +88,129 @@ func (b *Blade) TlsKeyFile(f string) {
 }
 
 // Add registers a new handler for the given method and path.
-func (b *Blade) Add(method, path string, handler Handler, m ...Middleware) {
+// It panics when the route conflicts with one already registered.
+// Routes may be added while serving requests.
+func (b *Blade) Add(method, path string, handler Handler, m ...Middleware) *Route {
+	return b.add("", method, path, handler, m, false)
+}
+
+// add registers the route for the given host pattern, the default host if empty.
+// Until requests are served the route is added to the routing in place,
+// afterwards a new routing is built and swapped in.
+func (b *Blade) add(hostname, method, path string, handler Handler, m []Middleware, replace bool) *Route {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
 	path = "/" + strings.Trim(path, "/")
+	if n := countParams(path) + hostParams(hostname); n > b.paramLimit {
+		panic(errors.Errorf("route '%s' has %d parameters, more than the limit of %d", path, n, b.paramLimit))
+	}
+
 	transform := b.transformMiddleware(m...)
-	b.router.Add(method, path, transform(handler))
+	r := newRoute(b, method, path, handler, slices.Concat(b.middleware, m))
+	r.Host = hostname
+	r.handler = transform(handler)
+
+	if !replace && !b.serving.Load() {
+		if err := b.routing.Load().add(r); err != nil {
+			panic(err)
+		}
+		b.routes = append(b.routes, r)
+		return r
+	}
+
+	routes := slices.Clone(b.routes)
+	if replace {
+		routes = slices.DeleteFunc(routes, func(old *Route) bool {
+			if old.Host != r.Host || old.Method != r.Method || old.Path != r.Path {
+				return false
+			}
+			r.Name = old.Name
+			r.metadata.Store(old.metadata.Load())
+			return true
+		})
+	}
+	routes = append(routes, r)
+
+	next, err := b.build(routes)
+	if err != nil {
+		panic(err)
+	}
+	b.commit(routes, next)
+	return r
 }
 
 // Get registers your function to be called when the given GET path has been requested.
-func (b *Blade) Get(path string, handler Handler, m ...Middleware) {
-	b.Add(http.MethodGet, path, handler, m...)
+func (b *Blade) Get(path string, handler Handler, m ...Middleware) *Route {
+	return b.Add(http.MethodGet, path, handler, m...)
 }
 
 // Post registers your function to be called when the given POST path has been requested.
-func (b *Blade) Post(path string, handler Handler, m ...Middleware) {
-	b.Add(http.MethodPost, path, handler, m...)
+func (b *Blade) Post(path string, handler Handler, m ...Middleware) *Route {
+	return b.Add(http.MethodPost, path, handler, m...)
 }
 
 // Put registers your function to be called when the given PUT path has been requested.
-func (b *Blade) Put(path string, handler Handler, m ...Middleware) {
-	b.Add(http.MethodPut, path, handler, m...)
+func (b *Blade) Put(path string, handler Handler, m ...Middleware) *Route {
+	return b.Add(http.MethodPut, path, handler, m...)
 }
 
 // Patch registers your function to be called when the given PATCH path has been requested.
-func (b *Blade) Patch(path string, handler Handler, m ...Middleware) {
-	b.Add(http.MethodPatch, path, handler, m...)
+func (b *Blade) Patch(path string, handler Handler, m ...Middleware) *Route {
+	return b.Add(http.MethodPatch, path, handler, m...)
 }
 
 // Delete registers your function to be called when the given DELETE path has been requested.
-func (b *Blade) Delete(path string, handler Handler, m ...Middleware) {
-	b.Add(http.MethodDelete, path, handler, m...)
+func (b *Blade) Delete(path string, handler Handler, m ...Middleware) *Route {
+	return b.Add(http.MethodDelete, path, handler, m...)
+}
+
+// Head registers your function to be called when the given HEAD path has been requested.
+func (b *Blade) Head(path string, handler Handler, m ...Middleware) *Route {
+	return b.Add(http.MethodHead, path, handler, m...)
+}
+
+// Options registers your function to be called when the given OPTIONS path has been requested.
+func (b *Blade) Options(path string, handler Handler, m ...Middleware) *Route {
+	return b.Add(http.MethodOptions, path, handler, m...)
+}
+
+// Any registers your function to be called when the given path has been requested with any method,
+// a route is created for every standard method and one for the others by MethodAny.
+func (b *Blade) Any(path string, handler Handler, m ...Middleware) []*Route {
+	routes := make([]*Route, 0, len(methods)+1)
+	for _, method := range methods {
+		routes = append(routes, b.Add(method, path, handler, m...))
+	}
+	return append(routes, b.Add(MethodAny, path, handler, m...))
+}
+
+// EnableAutoHead answers HEAD requests without a HEAD route by the GET handler,
+// the body is discarded while Content-Length is kept.
+func (b *Blade) EnableAutoHead() {
+	b.autoHead = true
+}
+
+// EnableAutoOptions answers OPTIONS requests without an OPTIONS route
+// with 204 and the methods registered for the path as Allow header.
+func (b *Blade) EnableAutoOptions() {
+	b.autoOptions = true
+}
+
+// EnablePrecompressed serves file.br or file.gz next to a file
+// by Context.File and StaticFS when the client accepts its encoding.
+func (b *Blade) EnablePrecompressed() {
+	b.precompress = true
 }
 
 // Bind static directory
 // h.Static("/static", "static/")
-func (b *Blade) Static(path, bind string, m ...Middleware) {
+func (b *Blade) Static(path, bind string, m ...Middleware) *Route {
 	relativePath := "/" + strings.Trim(path, "/") + "/*file"
 	handler := func(c *Context) error {
 		return c.File(bind + c.Get("file"))
 	}
-	b.Get(relativePath, handler, m...)
-}
-
-// Router returns the router used by the blade.
-func (b *Blade) Router() *Router[Handler] {
-	return b.router
+	return b.Get(relativePath, handler, m...)
 }
 
 // Router group
@@ -113,14 +222,32 @@ func (b *Blade) Group(name string, m ...Middleware) *Group {
 
 // ServeHTTP responds to the given request.
 func (b *Blade) ServeHTTP(response http.ResponseWriter, request *http.Request) {
+	if !b.serving.Load() {
+		b.startServing()
+	}
+
+	rt := b.routing.Load()
 	c := b.newContext(request, response)
-	c.handler = b.router.Lookup(request.Method, request.URL.Path, c.addParameter)
-	if c.handler == nil {
-		if b.notFoundFn != nil {
-			b.notFoundFn(c)
-		} else {
-			response.WriteHeader(http.StatusNotFound)
+	c.reserveParams(rt.maxParams)
+	router := rt.selectRouter(request.Host, c.addParameter)
+	hostParams := c.paramCount
+	head := b.lookup(c, router, request.URL.Path, hostParams)
+
+	if c.handler == nil && b.pathPolicy != PathStrict {
+		if fixed := b.fixPath(router, request.Method, request.URL.Path); fixed != "" {
+			if b.pathPolicy == PathRedirect {
+				b.redirectPath(c, fixed)
+				c.Close()
+				return
+			}
+			// Middleware checking the path sees the one of the route.
+			c.SetPath(fixed)
+			head = b.lookup(c, router, fixed, hostParams)
 		}
+	}
+
+	if c.handler == nil {
+		b.noRoute(c, router)
 		c.Close()
 		return
 	}
@@ -129,9 +256,94 @@ func (b *Blade) ServeHTTP(response http.ResponseWriter, request *http.Request) {
 	if err != nil {
 		b.errorHandler(c, err)
 	}
+	if head != nil {
+		head.flush()
+	}
 	c.Close()
 }
 
+// lookup sets the handler for the request method and the given path,
+// a HEAD request is answered by the GET handler if enabled.
+func (b *Blade) lookup(c *Context, router *Router[Handler], path string, hostParams int) *headResponseWriter {
+	method := c.request.Method()
+	c.paramCount = hostParams
+	c.handler = router.Lookup(method, path, c.addParameter)
+	if c.handler != nil || method != http.MethodHead || !b.autoHead {
+		return nil
+	}
+
+	c.paramCount = hostParams
+	c.handler = router.Lookup(http.MethodGet, path, c.addParameter)
+	if c.handler == nil {
+		return nil
+	}
+	head := &headResponseWriter{ResponseWriter: c.response.rw}
+	c.response.rw = head
+	return head
+}
+
+// noRoute responds to a request no handler has been found for.
+// Unless the path policy is strict, the methods of the fixed path count for 405 as well.
+func (b *Blade) noRoute(c *Context, router *Router[Handler]) {
+	method, path := c.request.Method(), c.request.Path()
+	allowed := b.allowed(router, method, path)
+	if len(allowed) == 0 && b.pathPolicy != PathStrict {
+		for _, candidate := range pathCandidates(path, b.pathPolicy == PathRedirect) {
+			if candidate == path {
+				continue
+			}
+			if allowed = b.allowed(router, method, candidate); len(allowed) > 0 {
+				break
+			}
+		}
+	}
+	if len(allowed) > 0 {
+		c.response.SetHeader(allowHeader, strings.Join(allowed, ", "))
+
+		if method == http.MethodOptions && b.autoOptions {
+			c.status = http.StatusNoContent
+			c.response.rw.WriteHeader(c.status)
+			return
+		}
+
+		c.status = http.StatusMethodNotAllowed
+		if b.notAllowedFn != nil {
+			b.notAllowedFn(c)
+		} else {
+			c.response.rw.WriteHeader(c.status)
+		}
+		return
+	}
+
+	b.notFound(c)
+}
+
+// notFound responds with 404 by the function set with NotFoundFn if any.
+func (b *Blade) notFound(c *Context) {
+	if b.notFoundFn != nil {
+		b.notFoundFn(c)
+	} else {
+		c.response.rw.WriteHeader(http.StatusNotFound)
+	}
+}
+
+// allowed returns the methods the given path can be requested with,
+// including the ones answered automatically.
+func (b *Blade) allowed(router *Router[Handler], method, path string) []string {
+	allowed := router.Allowed(method, path)
+	if len(allowed) == 0 {
+		return nil
+	}
+
+	if b.autoHead && method != http.MethodHead && !slices.Contains(allowed, http.MethodHead) && slices.Contains(allowed, http.MethodGet) {
+		allowed = append(allowed, http.MethodHead)
+	}
+	if b.autoOptions && !slices.Contains(allowed, http.MethodOptions) {
+		allowed = append(allowed, http.MethodOptions)
+	}
+	return allowed
+}
+
 // Run start your application with http(s)
 func (b *Blade) Run(addr string) error {
 	Log().Debug("Listening and serving HTTP(S)", zap.String("address", addr))
@@ -235,7 +447,9 @@ func (b *Blade) Shutdown(ctx context.Context) error {
 
 // Use adds middleware to your middleware chain.
 func (b *Blade) Use(m ...Middleware) {
+	b.mu.Lock()
 	b.middleware = append(b.middleware, m...)
+	b.mu.Unlock()
 }
 
 // newContext returns a new context from the pool.
@@ -245,6 +459,7 @@ func (b *Blade) newContext(req *http.Request, res http.ResponseWriter) *Context
 	c.request.req = req
 	c.response.rw = res
 	c.handler = nil
+	c.route = nil
 	c.paramCount = 0
 	c.sameSite = 0
 	c.keys = nil
@@ -0,0 +1,225 @@
+package hblade
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// serve sends the request to the blade and returns the recorded response.
+func serve(b *Blade, method, target string, header ...string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, nil)
+	for i := 0; i+1 < len(header); i += 2 {
+		req.Header.Set(header[i], header[i+1])
+	}
+	rec := httptest.NewRecorder()
+	b.ServeHTTP(rec, req)
+	return rec
+}
+
+// text returns a handler responding with the text.
+func text(s string) Handler {
+	return func(c *Context) error {
+		return c.Text(s)
+	}
+}
+
+func TestMethodNotAllowed(t *testing.T) {
+	app := New()
+	app.Get("/users", text("list"))
+	app.Post("/users", text("create"))
+	app.Delete("/users/:id", text("delete"))
+
+	tests := []struct {
+		method, path string
+		status       int
+		allow        string
+	}{
+		{http.MethodGet, "/users", http.StatusOK, ""},
+		{http.MethodPut, "/users", http.StatusMethodNotAllowed, "GET, POST"},
+		{http.MethodGet, "/users/5", http.StatusMethodNotAllowed, "DELETE"},
+		{http.MethodGet, "/nope", http.StatusNotFound, ""},
+	}
+
+	for _, test := range tests {
+		rec := serve(app, test.method, test.path)
+		if rec.Code != test.status || rec.Header().Get(allowHeader) != test.allow {
+			t.Errorf("%s %s = %d Allow %q, want %d Allow %q",
+				test.method, test.path, rec.Code, rec.Header().Get(allowHeader), test.status, test.allow)
+		}
+	}
+}
+
+func TestAutoHeadOptions(t *testing.T) {
+	app := New()
+	app.EnableAutoHead()
+	app.EnableAutoOptions()
+	app.Get("/users", text("list"))
+	app.Post("/users", text("create"))
+
+	rec := serve(app, http.MethodHead, "/users")
+	if rec.Code != http.StatusOK || rec.Body.Len() != 0 || rec.Header().Get(contentLengthHeader) != "4" {
+		t.Errorf("HEAD /users = %d %q Content-Length %q", rec.Code, rec.Body.String(), rec.Header().Get(contentLengthHeader))
+	}
+
+	rec = serve(app, http.MethodOptions, "/users")
+	if allow := rec.Header().Get(allowHeader); rec.Code != http.StatusNoContent || allow != "GET, POST, HEAD, OPTIONS" {
+		t.Errorf("OPTIONS /users = %d Allow %q", rec.Code, allow)
+	}
+
+	rec = serve(app, http.MethodPut, "/users")
+	if allow := rec.Header().Get(allowHeader); rec.Code != http.StatusMethodNotAllowed || allow != "GET, POST, HEAD, OPTIONS" {
+		t.Errorf("PUT /users = %d Allow %q", rec.Code, allow)
+	}
+}
+
+func TestHostRouting(t *testing.T) {
+	app := New()
+	app.Get("/", text("default"))
+	app.Host("api.example.com").Get("/", text("api"))
+	app.Host(":tenant.example.com").Get("/", func(c *Context) error {
+		return c.Text("tenant " + c.Get("tenant"))
+	})
+
+	tests := []struct{ host, body string }{
+		{"api.example.com", "api"},
+		{"API.example.com:8080", "api"},
+		{"acme.example.com", "tenant acme"},
+		{"example.com", "default"},
+		{"other.org", "default"},
+	}
+
+	for _, test := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Host = test.host
+		rec := httptest.NewRecorder()
+		app.ServeHTTP(rec, req)
+		if rec.Body.String() != test.body {
+			t.Errorf("host %s = %q, want %q", test.host, rec.Body.String(), test.body)
+		}
+	}
+}
+
+func TestETag(t *testing.T) {
+	body := strings.Repeat("hello ", 100)
+	for _, mode := range []ETagMode{ETagWeak, ETagStrong} {
+		app := New()
+		app.ETagMode(mode)
+		app.Get("/", text(body))
+
+		rec := serve(app, http.MethodGet, "/")
+		etag := rec.Header().Get(etagHeader)
+		if rec.Code != http.StatusOK || etag == "" {
+			t.Fatalf("mode %d: GET = %d ETag %q", mode, rec.Code, etag)
+		}
+		if weak := strings.HasPrefix(etag, "W/"); weak != (mode == ETagWeak) {
+			t.Errorf("mode %d: ETag %q", mode, etag)
+		}
+
+		rec = serve(app, http.MethodGet, "/", ifNoneMatchHeader, etag)
+		if rec.Code != http.StatusNotModified || rec.Body.Len() != 0 {
+			t.Errorf("mode %d: If-None-Match %s = %d %q", mode, etag, rec.Code, rec.Body.String())
+		}
+
+		// The ETag of the compressed response matches the uncompressed one.
+		rec = serve(app, http.MethodGet, "/", acceptEncodingHeader, "gzip")
+		gzipETag := rec.Header().Get(etagHeader)
+		rec = serve(app, http.MethodGet, "/", ifNoneMatchHeader, gzipETag)
+		if rec.Code != http.StatusNotModified {
+			t.Errorf("mode %d: If-None-Match %s = %d", mode, gzipETag, rec.Code)
+		}
+
+		rec = serve(app, http.MethodGet, "/", ifNoneMatchHeader, `"other"`)
+		if rec.Code != http.StatusOK {
+			t.Errorf("mode %d: If-None-Match other = %d", mode, rec.Code)
+		}
+	}
+}
+
+func TestCompressionNegotiation(t *testing.T) {
+	app := New()
+	app.Compression(CompressionConfig{
+		Encoders: []Encoder{NewZstdEncoder(3), NewBrotliEncoder(4), NewGzipEncoder(-1)},
+		MinSize:  256,
+	})
+	app.Get("/", text(strings.Repeat("hello ", 100)))
+	app.Get("/small", text("hello"))
+
+	tests := []struct {
+		path, acceptEncoding, encoding string
+	}{
+		{"/", "", ""},
+		{"/", "gzip", "gzip"},
+		{"/", "gzip, br", "br"},
+		{"/", "gzip, br, zstd", "zstd"},
+		{"/", "gzip;q=1, br;q=0.5", "gzip"},
+		{"/", "*", "zstd"},
+		{"/", "br;q=0, *", "zstd"},
+		{"/", "identity", ""},
+		{"/", "gzip;q=0", ""},
+		{"/small", "gzip", ""},
+		{"/small", "gzip, identity;q=0", "gzip"},
+		{"/small", "deflate, identity;q=0", ""},
+	}
+
+	for _, test := range tests {
+		rec := serve(app, http.MethodGet, test.path, acceptEncodingHeader, test.acceptEncoding)
+		if encoding := rec.Header().Get(contentEncodingHeader); rec.Code != http.StatusOK || encoding != test.encoding {
+			t.Errorf("%s Accept-Encoding %q = %d %q, want %q", test.path, test.acceptEncoding, rec.Code, encoding, test.encoding)
+		}
+		if rec.Header().Get(varyHeader) != acceptEncodingHeader {
+			t.Errorf("%s Accept-Encoding %q: Vary %q", test.path, test.acceptEncoding, rec.Header().Get(varyHeader))
+		}
+	}
+}
+
+func TestPathPolicy(t *testing.T) {
+	newApp := func(policy PathPolicy) *Blade {
+		app := New()
+		app.PathPolicy(policy)
+		app.EnableCaseInsensitivePath()
+		app.Use(func(next Handler) Handler {
+			return func(c *Context) error {
+				if strings.HasPrefix(c.Path(), "/admin") {
+					c.SetStatus(http.StatusForbidden)
+					return c.Text("forbidden")
+				}
+				return next(c)
+			}
+		})
+		app.Get("/admin/secret", text("secret"))
+		app.Get("/public/page", text("page"))
+		return app
+	}
+
+	tests := []struct {
+		policy   PathPolicy
+		path     string
+		status   int
+		location string
+	}{
+		{PathLenient, "/admin/secret", http.StatusForbidden, ""},
+		{PathLenient, "/admin/secret/", http.StatusForbidden, ""},
+		{PathLenient, "/ADMIN/secret", http.StatusForbidden, ""},
+		{PathLenient, "/public/page/", http.StatusOK, ""},
+		{PathLenient, "/public/../admin/secret", http.StatusNotFound, ""},
+		{PathLenient, "//admin/secret", http.StatusNotFound, ""},
+		{PathLenient, "/./admin/secret", http.StatusNotFound, ""},
+		{PathStrict, "/public/page/", http.StatusNotFound, ""},
+		{PathRedirect, "/public/page/", http.StatusMovedPermanently, "/public/page"},
+		{PathRedirect, "/public/../admin/secret", http.StatusMovedPermanently, "/admin/secret"},
+		{PathRedirect, "//admin/secret", http.StatusMovedPermanently, "/admin/secret"},
+	}
+
+	for _, test := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.URL.Path = test.path
+		rec := httptest.NewRecorder()
+		newApp(test.policy).ServeHTTP(rec, req)
+		if rec.Code != test.status || rec.Header().Get("Location") != test.location {
+			t.Errorf("policy %d: GET %s = %d %q, want %d %q",
+				test.policy, test.path, rec.Code, rec.Header().Get("Location"), test.status, test.location)
+		}
+	}
+}
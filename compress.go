@@ -0,0 +1,194 @@
+package hblade
+
+import (
+	"compress/flate"
+	"compress/gzip"
+	"io"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+)
+
+// Encoder compresses responses with a content coding.
+type Encoder interface {
+	// Encoding returns the content coding, e.g. gzip.
+	Encoding() string
+	// NewWriter returns a writer compressing to w.
+	NewWriter(w io.Writer) (io.WriteCloser, error)
+}
+
+// CompressionConfig configures compressing responses by Context.Bytes.
+type CompressionConfig struct {
+	// Encoders lists the encoders by preference,
+	// the one the client accepts with the highest quality is used.
+	Encoders []Encoder
+	// MinSize is the size from which responses are compressed,
+	// smaller ones too if the client refuses identity, e.g. by identity;q=0.
+	// When it accepts none of the encoders the response is sent as it is.
+	MinSize int
+	// Types lists the compressed media types, patterns like text/* are matched by path.Match.
+	// If empty all types are compressed except images other than SVG, video and audio.
+	// Responses without Content-Type are compressed as well.
+	Types []string
+}
+
+// defaultCompression compresses with gzip, the default level costs
+// far less CPU than the best one for slightly larger responses.
+var defaultCompression = CompressionConfig{
+	Encoders: []Encoder{NewGzipEncoder(gzip.DefaultCompression)},
+	MinSize:  gzipThreshold,
+}
+
+// Compression sets how responses are compressed, by default with gzip from 256 bytes on.
+// Encoders fall back to the default ones if empty.
+//
+//	h.Compression(hblade.CompressionConfig{
+//		Encoders: []hblade.Encoder{
+//			hblade.NewZstdEncoder(3),
+//			hblade.NewBrotliEncoder(4),
+//			hblade.NewGzipEncoder(gzip.DefaultCompression),
+//		},
+//		MinSize: 1024,
+//	})
+func (b *Blade) Compression(config CompressionConfig) {
+	if len(config.Encoders) == 0 {
+		config.Encoders = defaultCompression.Encoders
+	}
+	b.compression = config
+}
+
+// compressible reports whether responses of the content type are compressed.
+func (config *CompressionConfig) compressible(contentType string) bool {
+	if contentType == "" {
+		return true
+	}
+
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	mediaType = strings.ToLower(strings.TrimSpace(mediaType))
+	if len(config.Types) == 0 {
+		switch {
+		case strings.HasPrefix(mediaType, "image/"):
+			return mediaType == contentTypeSVG
+		case strings.HasPrefix(mediaType, "video/"), strings.HasPrefix(mediaType, "audio/"):
+			return false
+		}
+		return true
+	}
+	for _, pattern := range config.Types {
+		if ok, _ := path.Match(pattern, mediaType); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// negotiate returns the encoder the Accept-Encoding header gives the highest quality,
+// the earlier one on a tie, or nil if none is acceptable.
+func (config *CompressionConfig) negotiate(acceptEncoding string) Encoder {
+	if acceptEncoding == "" {
+		return nil
+	}
+
+	var (
+		best    Encoder
+		quality float64
+	)
+	for _, encoder := range config.Encoders {
+		if q := encodingQuality(acceptEncoding, encoder.Encoding()); q > quality {
+			best, quality = encoder, q
+		}
+	}
+	return best
+}
+
+// NewGzipEncoder returns the gzip encoder with a level of compress/gzip.
+func NewGzipEncoder(level int) Encoder {
+	return newPooledEncoder(contentEncodingGzip, func(w io.Writer) (resetWriter, error) {
+		return gzip.NewWriterLevel(w, level)
+	})
+}
+
+// NewDeflateEncoder returns the deflate encoder with a level of compress/flate.
+func NewDeflateEncoder(level int) Encoder {
+	return newPooledEncoder(contentEncodingDeflate, func(w io.Writer) (resetWriter, error) {
+		return flate.NewWriter(w, level)
+	})
+}
+
+// NewBrotliEncoder returns the brotli encoder with a level from 0 to 11.
+func NewBrotliEncoder(level int) Encoder {
+	return newPooledEncoder(contentEncodingBrotli, func(w io.Writer) (resetWriter, error) {
+		return brotli.NewWriterLevel(w, level), nil
+	})
+}
+
+// NewZstdEncoder returns the zstd encoder with a level from 1 to 22
+// mapped to the nearest level of the encoder.
+func NewZstdEncoder(level int) Encoder {
+	return newPooledEncoder(contentEncodingZstd, func(w io.Writer) (resetWriter, error) {
+		return zstd.NewWriter(w, zstd.WithEncoderLevel(zstd.EncoderLevelFromZstd(level)), zstd.WithEncoderConcurrency(1))
+	})
+}
+
+// resetWriter is a compressing writer which can be reused for another destination.
+type resetWriter interface {
+	io.WriteCloser
+	Reset(w io.Writer)
+}
+
+// pooledEncoder reuses the writers it returns once they have been closed.
+type pooledEncoder struct {
+	encoding  string
+	newWriter func(io.Writer) (resetWriter, error)
+	pool      sync.Pool
+}
+
+func newPooledEncoder(encoding string, newWriter func(io.Writer) (resetWriter, error)) *pooledEncoder {
+	return &pooledEncoder{encoding: encoding, newWriter: newWriter}
+}
+
+func (e *pooledEncoder) Encoding() string {
+	return e.encoding
+}
+
+func (e *pooledEncoder) NewWriter(w io.Writer) (io.WriteCloser, error) {
+	if pw, ok := e.pool.Get().(*pooledWriter); ok {
+		pw.Reset(w)
+		pw.closed = false
+		return pw, nil
+	}
+
+	rw, err := e.newWriter(w)
+	if err != nil {
+		return nil, err
+	}
+	return &pooledWriter{resetWriter: rw, pool: &e.pool}, nil
+}
+
+// pooledWriter puts itself back into the pool when closed.
+type pooledWriter struct {
+	resetWriter
+	pool   *sync.Pool
+	closed bool
+}
+
+func (w *pooledWriter) Flush() error {
+	if flusher, ok := w.resetWriter.(interface{ Flush() error }); ok {
+		return flusher.Flush()
+	}
+	return nil
+}
+
+func (w *pooledWriter) Close() error {
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+	err := w.resetWriter.Close()
+	w.Reset(io.Discard)
+	w.pool.Put(w)
+	return err
+}
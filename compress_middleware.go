@@ -0,0 +1,162 @@
+package hblade
+
+import (
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Compress returns a middleware compressing the responses written in any way,
+// e.g. by Context.File, Reader, Stream or Rw(), with the compression config of the blade.
+// Whether to compress is decided on the first write from the Content-Type and size,
+// Range requests, upgrades like WebSocket and already encoded responses are left as they are.
+func Compress() Middleware {
+	return func(next Handler) Handler {
+		return func(c *Context) error {
+			if c.request.Method() == http.MethodHead || c.request.Header(rangeHeader) != "" || c.request.Header(acceptEncodingHeader) == "" || isUpgrade(c.request.req) {
+				return next(c)
+			}
+
+			w := &compressResponseWriter{ResponseWriter: c.response.rw, c: c}
+			c.response.rw = w
+			err := next(c)
+			if closeErr := w.close(); err == nil {
+				err = closeErr
+			}
+			c.response.rw = w.ResponseWriter
+			return err
+		}
+	}
+}
+
+// isUpgrade reports whether the request asks to switch the protocol, e.g. to WebSocket,
+// the connection is then hijacked and mustn't be written to.
+func isUpgrade(r *http.Request) bool {
+	if r.Header.Get(upgradeHeader) != "" {
+		return true
+	}
+	for _, value := range r.Header.Values("Connection") {
+		for token := range strings.SplitSeq(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(token), "upgrade") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// compressResponseWriter buffers the body until it can decide whether to compress it.
+type compressResponseWriter struct {
+	http.ResponseWriter
+	c       *Context
+	status  int
+	buf     []byte
+	writer  io.WriteCloser
+	decided bool
+}
+
+func (w *compressResponseWriter) WriteHeader(status int) {
+	if status >= 100 && status < 200 {
+		w.ResponseWriter.WriteHeader(status)
+		return
+	}
+	if w.status == 0 {
+		w.status = status
+	}
+}
+
+func (w *compressResponseWriter) Write(b []byte) (int, error) {
+	if !w.decided {
+		w.buf = append(w.buf, b...)
+		if len(w.buf) < w.c.b.compression.MinSize {
+			return len(b), nil
+		}
+		if err := w.decide(true); err != nil {
+			return 0, err
+		}
+		return len(b), nil
+	}
+
+	if w.writer != nil {
+		return w.writer.Write(b)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush decides with the body written so far since a flushed response is streamed.
+func (w *compressResponseWriter) Flush() {
+	if !w.decided && w.decide(true) != nil {
+		return
+	}
+	if flusher, ok := w.writer.(interface{ Flush() error }); ok {
+		flusher.Flush()
+	}
+	http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+func (w *compressResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// decide writes the header, compressing if wanted and the response allows it,
+// and the buffered body.
+func (w *compressResponseWriter) decide(compress bool) error {
+	w.decided = true
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+
+	header := w.ResponseWriter.Header()
+	if len(w.buf) > 0 && header.Get(contentTypeHeader) == "" && header.Get(contentEncodingHeader) == "" {
+		header.Set(contentTypeHeader, http.DetectContentType(w.buf))
+	}
+
+	config := &w.c.b.compression
+	if contentType := header.Get(contentTypeHeader); contentType != "" && w.compressible() && config.compressible(contentType) {
+		addVary(header, acceptEncodingHeader)
+		if encoder := config.negotiate(w.c.request.Header(acceptEncodingHeader)); compress && encoder != nil {
+			writer, err := encoder.NewWriter(w.ResponseWriter)
+			if err != nil {
+				return err
+			}
+			w.writer = writer
+			header.Del(contentLengthHeader)
+			header.Set(contentEncodingHeader, encoder.Encoding())
+			if etag := header.Get(etagHeader); etag != "" {
+				header.Set(etagHeader, encodedETag(etag, encoder.Encoding()))
+			}
+		}
+	}
+
+	w.ResponseWriter.WriteHeader(w.status)
+	buf := w.buf
+	w.buf = nil
+	if len(buf) == 0 {
+		return nil
+	}
+	_, err := w.Write(buf)
+	return err
+}
+
+// compressible reports whether the status and headers allow compressing.
+func (w *compressResponseWriter) compressible() bool {
+	switch w.status {
+	case http.StatusNoContent, http.StatusNotModified, http.StatusPartialContent:
+		return false
+	}
+	return w.ResponseWriter.Header().Get(contentEncodingHeader) == ""
+}
+
+// close sends what's left when the handler returned.
+func (w *compressResponseWriter) close() error {
+	// The body is too small to be compressed unless the client refuses identity.
+	if !w.decided {
+		if err := w.decide(identityExcluded(w.c.request.Header(acceptEncodingHeader))); err != nil {
+			return err
+		}
+	}
+	if w.writer != nil {
+		return w.writer.Close()
+	}
+	return nil
+}
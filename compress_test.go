@@ -0,0 +1,94 @@
+package hblade
+
+import (
+	"compress/gzip"
+	"io"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestCompressible(t *testing.T) {
+	custom := CompressionConfig{Types: []string{"text/*", "application/json"}}
+	tests := []struct {
+		config      *CompressionConfig
+		contentType string
+		compressed  bool
+	}{
+		{&defaultCompression, "", true},
+		{&defaultCompression, "text/html; charset=utf-8", true},
+		{&defaultCompression, "application/yaml", true},
+		{&defaultCompression, "application/toml", true},
+		{&defaultCompression, "application/x-msgpack", true},
+		{&defaultCompression, "application/pdf", true},
+		{&defaultCompression, "image/svg+xml", true},
+		{&defaultCompression, "image/png", false},
+		{&defaultCompression, "Video/MP4", false},
+		{&defaultCompression, "audio/ogg", false},
+		{&custom, "application/json", true},
+		{&custom, "TEXT/Plain; charset=utf-8", true},
+		{&custom, "application/yaml", false},
+		{&custom, "image/svg+xml", false},
+	}
+
+	for _, test := range tests {
+		if compressed := test.config.compressible(test.contentType); compressed != test.compressed {
+			t.Errorf("compressible(%q) with types %q = %t", test.contentType, test.config.Types, compressed)
+		}
+	}
+}
+
+// benchBody returns a JSON array of about 12 KB like an API response.
+func benchBody() []byte {
+	var sb strings.Builder
+	sb.WriteString("[")
+	for i := range 200 {
+		if i > 0 {
+			sb.WriteString(",")
+		}
+		sb.WriteString(`{"id":` + strconv.Itoa(i) + `,"name":"resource ` + strconv.Itoa(i) + `","tags":["a","b"],"active":true}`)
+	}
+	sb.WriteString("]")
+	return []byte(sb.String())
+}
+
+// BenchmarkGzip compresses a response with a new writer per response
+// as before pooling and with the pooled encoders.
+func BenchmarkGzip(b *testing.B) {
+	body := benchBody()
+
+	b.Run("new/best", func(b *testing.B) {
+		b.ReportAllocs()
+		b.SetBytes(int64(len(body)))
+		for b.Loop() {
+			w, err := gzip.NewWriterLevel(io.Discard, gzip.BestCompression)
+			if err != nil {
+				b.Fatal(err)
+			}
+			w.Write(body)
+			w.Close()
+		}
+	})
+
+	for _, level := range []struct {
+		name  string
+		level int
+	}{
+		{"best", gzip.BestCompression},
+		{"default", gzip.DefaultCompression},
+	} {
+		encoder := NewGzipEncoder(level.level)
+		b.Run("pooled/"+level.name, func(b *testing.B) {
+			b.ReportAllocs()
+			b.SetBytes(int64(len(body)))
+			for b.Loop() {
+				w, err := encoder.NewWriter(io.Discard)
+				if err != nil {
+					b.Fatal(err)
+				}
+				w.Write(body)
+				w.Close()
+			}
+		})
+	}
+}
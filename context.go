@@ -2,13 +2,13 @@ package hblade
 
 import (
 	"bytes"
-	"compress/gzip"
 	"errors"
 	"io"
 	"mime"
 	"net"
 	"net/http"
 	"net/url"
+	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
@@ -24,9 +24,9 @@ const (
 	// 此值应接近TCP包大小,设置为256将最终数据包的大小减少了约70个字节
 	gzipThreshold = 256
 
-	// 路由最大参数
-	maxParams    = 64
-	BodyBytesKey = "hblade_bodybyteskey"
+	// 路由默认最大参数
+	defaultParamLimit = 64
+	BodyBytesKey      = "hblade_bodybyteskey"
 )
 
 // Context represents a request & response context.
@@ -36,8 +36,9 @@ type Context struct {
 	request     request
 	response    response
 	handler     Handler
-	paramNames  [maxParams]string
-	paramValues [maxParams]string
+	route       *Route
+	paramNames  []string
+	paramValues []string
 	paramCount  int
 	sameSite    http.SameSite
 	mu          sync.RWMutex
@@ -163,16 +164,42 @@ func (c *Context) Bytes(body []byte) error {
 		return errors.New("Request interrupted by the client")
 	}
 
+	// Content type
+	header := c.response.rw.Header()
+	contentType := header.Get(contentTypeHeader)
+
+	// Compression, a client refusing identity gets any coding it accepts whatever the size.
+	small := len(body) < c.b.compression.MinSize
+	var encoder Encoder
+	encoding := ""
+	if c.b.compression.compressible(contentType) {
+		addVary(header, acceptEncodingHeader)
+		if acceptEncoding := c.request.Header(acceptEncodingHeader); !small || identityExcluded(acceptEncoding) {
+			encoder = c.b.compression.negotiate(acceptEncoding)
+			small = small && encoder == nil
+		}
+	}
+	if encoder != nil {
+		encoding = encoder.Encoding()
+
+		// Sniffing the compressed body would find the compression format.
+		if contentType == "" {
+			header.Set(contentTypeHeader, http.DetectContentType(body))
+		}
+	}
+
+	// Conditional GET
+	if c.notModified(body, encoding) {
+		return nil
+	}
+
 	// Small response
-	if len(body) < gzipThreshold {
+	if small {
 		c.response.rw.WriteHeader(c.status)
 		_, err := c.response.rw.Write(body)
 		return err
 	}
 
-	// Content type
-	header := c.response.rw.Header()
-	contentType := header.Get(contentTypeHeader)
 	isMediaType := isMedia(contentType)
 
 	// Cache control header
@@ -182,32 +209,42 @@ func (c *Context) Bytes(body []byte) error {
 		header.Set(cacheControlHeader, cacheControlAlwaysValidate)
 	}
 
-	// No GZip?
-	clientSupportsGZip := strings.Contains(c.request.Header(acceptEncodingHeader), "gzip")
-
-	if !clientSupportsGZip || !canCompress(contentType) {
+	// No compression?
+	if encoder == nil {
 		header.Set(contentLengthHeader, strconv.Itoa(len(body)))
 		c.response.rw.WriteHeader(c.status)
 		_, err := c.response.rw.Write(body)
 		return err
 	}
 
-	// GZip
-	header.Set(contentEncodingHeader, contentEncodingGzip)
+	writer, err := encoder.NewWriter(c.response.rw)
+	if err != nil {
+		return err
+	}
+	header.Set(contentEncodingHeader, encoding)
 	c.response.rw.WriteHeader(c.status)
 
 	// Write response body
-	writer, _ := gzip.NewWriterLevel(c.response.rw, gzip.BestCompression)
-	_, err := writer.Write(body)
-	writer.Close()
+	_, err = writer.Write(body)
+	if closeErr := writer.Close(); err == nil {
+		err = closeErr
+	}
 
 	// Return the error value of the last Write call
 	return err
 }
 
+// reserveParams makes room for the given number of parameters.
+func (c *Context) reserveParams(n int) {
+	if len(c.paramNames) < n {
+		c.paramNames = make([]string, n)
+		c.paramValues = make([]string, n)
+	}
+}
+
 // addParameter adds a new parameter to the context.
 func (c *Context) addParameter(name string, value string) {
-	if c.paramCount >= maxParams {
+	if c.paramCount >= len(c.paramNames) {
 		return
 	}
 	c.paramNames[c.paramCount] = name
@@ -255,6 +292,7 @@ func (c *Context) Close() {
 	c.request.req = nil
 	c.response.rw = nil
 	c.handler = nil
+	c.route = nil
 	c.paramCount = 0
 	c.sameSite = 0
 	c.keys = nil
@@ -283,6 +321,20 @@ func (c *Context) File(file string) error {
 		c.response.SetHeader(cacheControlHeader, cacheControlMedia)
 	}
 
+	if c.b.precompress && contentType != "" {
+		if encoded, encoding := c.precompressed(file, os.Stat); encoded != "" {
+			if f, err := os.Open(encoded); err == nil {
+				defer f.Close()
+				if info, err := f.Stat(); err == nil {
+					c.response.SetHeader(contentTypeHeader, contentType)
+					c.response.SetHeader(contentEncodingHeader, encoding)
+					http.ServeContent(c.response.rw, c.request.req, info.Name(), info.ModTime(), f)
+					return nil
+				}
+			}
+		}
+	}
+
 	http.ServeFile(c.response.rw, c.request.req, file)
 	return nil
 }
@@ -342,6 +394,16 @@ func (c *Context) Get(param string) string {
 	return ""
 }
 
+// URLFor builds the path of the named route, see Blade.URL.
+func (c *Context) URLFor(name string, params ...string) (string, error) {
+	return c.b.URL(name, params...)
+}
+
+// Route returns the route handling the request, nil when there is none e.g. for 404.
+func (c *Context) Route() *Route {
+	return c.route
+}
+
 // GetInt retrieves an URL parameter as an integer.
 func (c *Context) GetInt(param string) (int, error) {
 	return strconv.Atoi(c.Get(param))
@@ -404,20 +466,6 @@ func isMedia(contentType string) bool {
 	}
 }
 
-// canCompress returns whether the given content type should be compressed via gzip.
-func canCompress(contentType string) bool {
-	switch {
-	case strings.HasPrefix(contentType, "image/") && contentType != contentTypeSVG:
-		return false
-	case strings.HasPrefix(contentType, "video/"):
-		return false
-	case strings.HasPrefix(contentType, "audio/"):
-		return false
-	default:
-		return true
-	}
-}
-
 // ReadAll returns the contents of the reader.
 // This will create an in-memory copy and calculate the E-Tag before sending the data.
 // Compression will be applied if necessary.
@@ -0,0 +1,52 @@
+package hblade
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// addVary adds the header name to the Vary header unless it's already listed.
+func addVary(header http.Header, name string) {
+	for _, value := range header.Values(varyHeader) {
+		for field := range strings.SplitSeq(value, ",") {
+			if field = strings.TrimSpace(field); field == "*" || strings.EqualFold(field, name) {
+				return
+			}
+		}
+	}
+	header.Add(varyHeader, name)
+}
+
+// encodingQuality returns the quality between 0 and 1
+// the Accept-Encoding header gives the content coding.
+func encodingQuality(header, coding string) float64 {
+	quality := 0.0
+	for part := range strings.SplitSeq(header, ",") {
+		name, params, _ := strings.Cut(part, ";")
+		name = strings.TrimSpace(name)
+
+		q := 1.0
+		for param := range strings.SplitSeq(params, ";") {
+			key, value, _ := strings.Cut(param, "=")
+			if strings.EqualFold(strings.TrimSpace(key), "q") {
+				q, _ = strconv.ParseFloat(strings.TrimSpace(value), 64)
+			}
+		}
+
+		switch {
+		case strings.EqualFold(name, coding):
+			return q
+		case name == "*":
+			quality = q
+		}
+	}
+	return quality
+}
+
+// identityExcluded reports whether the Accept-Encoding header refuses responses
+// without content coding by identity;q=0, or by *;q=0 without identity.
+func identityExcluded(header string) bool {
+	// The leading * makes identity acceptable unless a later entry says otherwise.
+	return header != "" && encodingQuality("*,"+header, "identity") == 0
+}
@@ -0,0 +1,126 @@
+package hblade
+
+import (
+	"hash/fnv"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// ETagMode decides whether Context.Bytes sends an ETag computed from the body.
+type ETagMode uint8
+
+const (
+	// ETagOff sends no ETag, it's the default.
+	ETagOff ETagMode = iota
+	// ETagWeak sends a weak ETag, e.g. W/"1f2e3d4c5b6a7980".
+	ETagWeak
+	// ETagStrong sends a strong ETag, suffixed by the content coding when compressed.
+	ETagStrong
+)
+
+// etagEncodings lists the content codings a strong ETag can be suffixed by.
+var etagEncodings = [...]string{contentEncodingGzip, contentEncodingBrotli, contentEncodingDeflate, contentEncodingZstd}
+
+// ETagMode sets whether Context.Bytes computes an ETag,
+// requests conditional on it are answered with 304.
+func (b *Blade) ETagMode(m ETagMode) {
+	b.etagMode = m
+}
+
+// notModified sets the ETag of the body sent with the given content coding if enabled
+// and reports whether 304 has been sent since the If-None-Match or If-Modified-Since
+// header of the request matches.
+func (c *Context) notModified(body []byte, encoding string) bool {
+	method := c.request.Method()
+	if c.status != http.StatusOK || (method != http.MethodGet && method != http.MethodHead) {
+		return false
+	}
+
+	header := c.response.rw.Header()
+	etag := header.Get(etagHeader)
+	if etag == "" && c.b.etagMode != ETagOff {
+		etag = newETag(body, c.b.etagMode == ETagWeak)
+		header.Set(etagHeader, etag)
+	}
+	if etag != "" && encoding != "" {
+		etag = encodedETag(etag, encoding)
+		header.Set(etagHeader, etag)
+	}
+
+	if !isNotModified(c.request.req, etag, header.Get(lastModifiedHeader)) {
+		return false
+	}
+
+	header.Del(contentTypeHeader)
+	header.Del(contentLengthHeader)
+	header.Del(contentEncodingHeader)
+	c.status = http.StatusNotModified
+	c.response.rw.WriteHeader(c.status)
+	return true
+}
+
+// newETag returns the ETag of the body.
+func newETag(body []byte, weak bool) string {
+	h := fnv.New64a()
+	h.Write(body)
+	etag := `"` + strconv.FormatUint(h.Sum64(), 16) + `"`
+	if weak {
+		return "W/" + etag
+	}
+	return etag
+}
+
+// encodedETag returns the strong ETag suffixed by the content coding, weak ones are returned as is.
+func encodedETag(etag, encoding string) string {
+	if strings.HasPrefix(etag, "W/") {
+		return etag
+	}
+	return strings.TrimSuffix(etag, `"`) + "-" + encoding + `"`
+}
+
+// isNotModified evaluates the conditional headers of the request,
+// If-Modified-Since is only used without If-None-Match.
+func isNotModified(req *http.Request, etag, lastModified string) bool {
+	if ifNoneMatch := req.Header.Get(ifNoneMatchHeader); ifNoneMatch != "" {
+		return etag != "" && etagMatch(ifNoneMatch, etag)
+	}
+
+	ifModifiedSince := req.Header.Get(ifModifiedSinceHeader)
+	if ifModifiedSince == "" || lastModified == "" {
+		return false
+	}
+	since, err := http.ParseTime(ifModifiedSince)
+	if err != nil {
+		return false
+	}
+	modified, err := http.ParseTime(lastModified)
+	return err == nil && !modified.After(since)
+}
+
+// etagMatch compares the ETags of the If-None-Match header weakly with the given one.
+func etagMatch(ifNoneMatch, etag string) bool {
+	if strings.TrimSpace(ifNoneMatch) == "*" {
+		return true
+	}
+
+	opaque := opaqueTag(etag)
+	for tag := range strings.SplitSeq(ifNoneMatch, ",") {
+		if opaqueTag(tag) == opaque {
+			return true
+		}
+	}
+	return false
+}
+
+// opaqueTag returns the ETag without weakness indicator, quotes and content coding suffix.
+func opaqueTag(etag string) string {
+	etag = strings.TrimPrefix(strings.TrimSpace(etag), "W/")
+	etag = strings.Trim(etag, `"`)
+	for _, encoding := range etagEncodings {
+		if tag, ok := strings.CutSuffix(etag, "-"+encoding); ok {
+			return tag
+		}
+	}
+	return etag
+}
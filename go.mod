@@ -3,10 +3,12 @@ module github.com/zatxm/hblade/v5
 go 1.26.3
 
 require (
+	github.com/andybalholm/brotli v1.2.6
 	github.com/go-playground/validator/v10 v10.30.3
 	github.com/goccy/go-json v0.10.6
 	github.com/goccy/go-yaml v1.19.2
 	github.com/google/uuid v1.6.0
+	github.com/klauspost/compress v1.20.1
 	github.com/pelletier/go-toml/v2 v2.4.2
 	github.com/pkg/errors v0.9.1
 	github.com/ugorji/go/codec v1.3.1
@@ -1,6 +1,7 @@
 package hblade
 
 import (
+	"io/fs"
 	"net/http"
 	"strings"
 )
@@ -9,6 +10,7 @@ import (
 type Group struct {
 	name       string
 	app        *Blade
+	host       string
 	middleware []Middleware
 }
 
@@ -17,44 +19,89 @@ func (g *Group) Clear() {
 }
 
 // Add registers a new handler for the given method and path.
-func (g *Group) Add(method, path string, handler Handler, m ...Middleware) {
+func (g *Group) Add(method, path string, handler Handler, m ...Middleware) *Route {
 	path = g.name + "/" + strings.TrimLeft(path, "/")
 	mw := append(g.middleware, m...)
-	g.app.Add(method, path, handler, mw...)
+	return g.app.add(g.host, method, path, handler, mw, false)
+}
+
+// Replace registers a new handler for the given method and path,
+// replacing the route registered for them before, see Blade.Replace.
+func (g *Group) Replace(method, path string, handler Handler, m ...Middleware) *Route {
+	path = g.name + "/" + strings.TrimLeft(path, "/")
+	mw := append(g.middleware, m...)
+	return g.app.add(g.host, method, path, handler, mw, true)
+}
+
+// Remove removes the route registered for the given method and path, see Blade.Remove.
+func (g *Group) Remove(method, path string) bool {
+	return g.app.remove(g.host, method, g.name+"/"+strings.TrimLeft(path, "/"))
 }
 
 // Get registers your function to be called when the given GET path has been requested.
-func (g *Group) Get(path string, handler Handler, m ...Middleware) {
-	g.Add(http.MethodGet, path, handler, m...)
+func (g *Group) Get(path string, handler Handler, m ...Middleware) *Route {
+	return g.Add(http.MethodGet, path, handler, m...)
 }
 
 // Post registers your function to be called when the given POST path has been requested.
-func (g *Group) Post(path string, handler Handler, m ...Middleware) {
-	g.Add(http.MethodPost, path, handler, m...)
+func (g *Group) Post(path string, handler Handler, m ...Middleware) *Route {
+	return g.Add(http.MethodPost, path, handler, m...)
 }
 
 // Put registers your function to be called when the given PUT path has been requested.
-func (g *Group) Put(path string, handler Handler, m ...Middleware) {
-	g.Add(http.MethodPut, path, handler, m...)
+func (g *Group) Put(path string, handler Handler, m ...Middleware) *Route {
+	return g.Add(http.MethodPut, path, handler, m...)
 }
 
 // Patch registers your function to be called when the given PATCH path has been requested.
-func (g *Group) Patch(path string, handler Handler, m ...Middleware) {
-	g.Add(http.MethodPatch, path, handler, m...)
+func (g *Group) Patch(path string, handler Handler, m ...Middleware) *Route {
+	return g.Add(http.MethodPatch, path, handler, m...)
 }
 
 // Delete registers your function to be called when the given DELETE path has been requested.
-func (g *Group) Delete(path string, handler Handler, m ...Middleware) {
-	g.Add(http.MethodDelete, path, handler, m...)
+func (g *Group) Delete(path string, handler Handler, m ...Middleware) *Route {
+	return g.Add(http.MethodDelete, path, handler, m...)
+}
+
+// Head registers your function to be called when the given HEAD path has been requested.
+func (g *Group) Head(path string, handler Handler, m ...Middleware) *Route {
+	return g.Add(http.MethodHead, path, handler, m...)
+}
+
+// Options registers your function to be called when the given OPTIONS path has been requested.
+func (g *Group) Options(path string, handler Handler, m ...Middleware) *Route {
+	return g.Add(http.MethodOptions, path, handler, m...)
+}
+
+// Any registers your function to be called when the given path has been requested with any method,
+// see Blade.Any.
+func (g *Group) Any(path string, handler Handler, m ...Middleware) []*Route {
+	routes := make([]*Route, 0, len(methods)+1)
+	for _, method := range methods {
+		routes = append(routes, g.Add(method, path, handler, m...))
+	}
+	return append(routes, g.Add(MethodAny, path, handler, m...))
 }
 
 // Bind static directory
-func (g *Group) Static(path, bind string, m ...Middleware) {
+func (g *Group) Static(path, bind string, m ...Middleware) *Route {
 	relativePath := strings.Trim(path, "/") + "/*file"
 	handler := func(c *Context) error {
 		return c.File(bind + c.Get("file"))
 	}
-	g.Get(relativePath, handler, m...)
+	return g.Get(relativePath, handler, m...)
+}
+
+// StaticFS serves the files of fsys under the prefix of the group, see Blade.StaticFS.
+func (g *Group) StaticFS(prefix string, fsys fs.FS, config StaticConfig, m ...Middleware) *Route {
+	return g.Get(strings.Trim(prefix, "/")+"/*file?", staticHandler(fsys, config), m...)
+}
+
+// Mount serves the given http.Handler for all paths under the prefix of the group,
+// see Blade.Mount.
+func (g *Group) Mount(prefix string, h http.Handler, m ...Middleware) []*Route {
+	prefix = strings.Trim(prefix, "/")
+	return g.Any(prefix+"/*path?", mountHandler("/"+strings.Trim(g.name+"/"+prefix, "/"), h), m...)
 }
 
 // Use adds middleware to your middleware chain.
@@ -66,6 +113,6 @@ func (g *Group) Use(m ...Middleware) {
 func (g *Group) Group(name string, m ...Middleware) *Group {
 	name = g.name + "/" + strings.Trim(name, "/")
 	mw := append(g.middleware, m...)
-	cg := &Group{app: g.app, name: name, middleware: mw}
+	cg := &Group{app: g.app, name: name, host: g.host, middleware: mw}
 	return cg
 }
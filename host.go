@@ -0,0 +1,114 @@
+package hblade
+
+import (
+	"slices"
+	"strings"
+)
+
+// host holds the routes of the hosts matching its pattern,
+// e.g. api.example.com or :tenant.example.com
+type host struct {
+	pattern string
+	params  int
+	router  *Router[Handler]
+}
+
+// Host returns a group whose routes are only served for the hosts matching the pattern.
+// Labels starting with ':' are parameters which can be retrieved by Context.Get:
+//
+//	t := h.Host(":tenant.example.com")
+//	t.Get("/", func(c *Context) error { return c.String(c.Get("tenant")) })
+func (b *Blade) Host(pattern string, m ...Middleware) *Group {
+	pattern = strings.ToLower(pattern)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	current := b.routing.Load()
+	if current.host(pattern) == nil {
+		next := &routing{router: current.router, hosts: slices.Clone(current.hosts), maxParams: current.maxParams}
+		h := &host{pattern: pattern, params: hostParams(pattern), router: &Router[Handler]{}}
+
+		// Hosts without parameters are matched first.
+		i := len(next.hosts)
+		if !strings.Contains(pattern, ":") {
+			i = slices.IndexFunc(next.hosts, func(h *host) bool { return strings.Contains(h.pattern, ":") })
+			if i == -1 {
+				i = len(next.hosts)
+			}
+		}
+		next.hosts = slices.Insert(next.hosts, i, h)
+		b.routing.Store(next)
+	}
+
+	return &Group{app: b, host: pattern, middleware: m}
+}
+
+// selectRouter returns the router for the given request host, the default one
+// if no host matches. Host parameters are reported by addParameter.
+func (rt *routing) selectRouter(hostname string, addParameter func(string, string)) *Router[Handler] {
+	if len(rt.hosts) == 0 {
+		return rt.router
+	}
+
+	hostname = stripPort(hostname)
+	for _, h := range rt.hosts {
+		if h.match(hostname, discardParameter) {
+			h.match(hostname, addParameter)
+			return h.router
+		}
+	}
+	return rt.router
+}
+
+// match reports whether the hostname matches the pattern label by label.
+func (h *host) match(hostname string, addParameter func(string, string)) bool {
+	pattern := h.pattern
+	for {
+		pEnd := strings.IndexByte(pattern, '.')
+		if pEnd == -1 {
+			pEnd = len(pattern)
+		}
+		hEnd := strings.IndexByte(hostname, '.')
+		if hEnd == -1 {
+			hEnd = len(hostname)
+		}
+
+		label := pattern[:pEnd]
+		value := hostname[:hEnd]
+		switch {
+		case value == "":
+			return false
+		case label != "" && label[0] == parameter:
+			addParameter(label[1:], value)
+		case !strings.EqualFold(label, value):
+			return false
+		}
+
+		if pEnd == len(pattern) || hEnd == len(hostname) {
+			return pEnd == len(pattern) && hEnd == len(hostname)
+		}
+		pattern = pattern[pEnd+1:]
+		hostname = hostname[hEnd+1:]
+	}
+}
+
+// hostParams returns the number of parameters in the host pattern.
+func hostParams(pattern string) int {
+	n := 0
+	for label := range strings.SplitSeq(pattern, ".") {
+		if strings.HasPrefix(label, ":") {
+			n++
+		}
+	}
+	return n
+}
+
+// stripPort removes the port from the host of a request.
+func stripPort(hostname string) string {
+	i := strings.LastIndexByte(hostname, ':')
+	if i == -1 || strings.IndexByte(hostname[i:], ']') != -1 {
+		return hostname
+	}
+	return hostname[:i]
+}
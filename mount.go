@@ -0,0 +1,87 @@
+package hblade
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Mount serves the given http.Handler, e.g. a file server or another *Blade,
+// for all paths under the prefix with any method, e.g. WebDAV's PROPFIND as well.
+// The prefix is stripped from the request path before it's passed on,
+// handlers expecting the full path like net/http/pprof are wrapped by WrapHandler instead.
+func (b *Blade) Mount(prefix string, h http.Handler, m ...Middleware) []*Route {
+	prefix = strings.Trim(prefix, "/")
+	return b.Any(prefix+"/*path?", mountHandler("/"+prefix, h), m...)
+}
+
+// WrapHandler turns a http.Handler into a Handler.
+func WrapHandler(h http.Handler) Handler {
+	return func(c *Context) error {
+		h.ServeHTTP(c.response.rw, c.request.req)
+		return nil
+	}
+}
+
+// WrapMiddleware turns a net/http middleware into a Middleware.
+// The response writer and request it passes on are used by the next handlers.
+func WrapMiddleware(mw func(http.Handler) http.Handler) Middleware {
+	return func(next Handler) Handler {
+		return func(c *Context) error {
+			rw, req := c.response.rw, c.request.req
+			defer func() {
+				c.response.rw, c.request.req = rw, req
+			}()
+
+			var err error
+			mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				c.response.rw, c.request.req = w, r
+				err = next(c)
+			})).ServeHTTP(rw, req)
+			return err
+		}
+	}
+}
+
+// StdMiddleware turns a Middleware into a net/http middleware,
+// its contexts are taken from the blade and errors go to its error handler.
+func (b *Blade) StdMiddleware(m Middleware) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		handler := m(WrapHandler(next))
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			c := b.newContext(r, w)
+			if err := handler(c); err != nil {
+				b.errorHandler(c, err)
+			}
+			c.Close()
+		})
+	}
+}
+
+// mountHandler passes requests to h with the prefix stripped from their path.
+func mountHandler(prefix string, h http.Handler) Handler {
+	prefix = strings.TrimSuffix(prefix, "/")
+	return func(c *Context) error {
+		req := c.request.req
+		r := new(http.Request)
+		*r = *req
+		r.URL = new(url.URL)
+		*r.URL = *req.URL
+		r.URL.Path = stripPrefix(req.URL.Path, prefix)
+		if req.URL.RawPath != "" {
+			r.URL.RawPath = stripPrefix(req.URL.RawPath, prefix)
+		}
+
+		h.ServeHTTP(c.response.rw, r)
+		return nil
+	}
+}
+
+// stripPrefix removes the prefix from the path, keeping it rooted.
+func stripPrefix(path, prefix string) string {
+	path = strings.TrimPrefix(path, prefix)
+	if path == "" {
+		return "/"
+	}
+	return path
+}
@@ -0,0 +1,93 @@
+package hblade
+
+import (
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+)
+
+// PathPolicy decides how requests are handled whose path only matches a route
+// after adding or removing the trailing slash or cleaning //, /./ and /../ from it.
+type PathPolicy uint8
+
+const (
+	// PathLenient serves paths differing in the trailing slash like the path of the route,
+	// which the request path is set to. It's the default.
+	PathLenient PathPolicy = iota
+	// PathStrict serves exactly matching paths only.
+	PathStrict
+	// PathRedirect redirects such paths to the path of the route, cleaned ones as well,
+	// with 301 for GET and HEAD requests and 308 for the others.
+	PathRedirect
+)
+
+// PathPolicy sets how paths not exactly matching a route are handled.
+func (b *Blade) PathPolicy(p PathPolicy) {
+	b.pathPolicy = p
+}
+
+// EnableCaseInsensitivePath lets paths differing from a route in case only
+// be handled like the other paths not exactly matching, see PathPolicy.
+func (b *Blade) EnableCaseInsensitivePath() {
+	b.fixCase = true
+}
+
+// fixPath returns the path of the route the given request path
+// matches after fixing it or an empty string if there is none.
+func (b *Blade) fixPath(router *Router[Handler], method, requestPath string) string {
+	candidates := pathCandidates(requestPath, b.pathPolicy == PathRedirect)
+	for _, candidate := range candidates {
+		if candidate != requestPath && b.has(router, method, candidate) {
+			return candidate
+		}
+	}
+
+	if !b.fixCase {
+		return ""
+	}
+
+	for _, candidate := range candidates {
+		if fixed, ok := router.FindCaseInsensitive(method, candidate); ok && fixed != requestPath {
+			return fixed
+		}
+		if method == http.MethodHead && b.autoHead {
+			if fixed, ok := router.FindCaseInsensitive(http.MethodGet, candidate); ok && fixed != requestPath {
+				return fixed
+			}
+		}
+	}
+	return ""
+}
+
+// pathCandidates returns the request path with and without trailing slash,
+// cleaned of //, /./ and /../ if clean is set. Only redirects use cleaned paths,
+// serving them would bypass middleware checking the path by e.g. /public/../admin.
+func pathCandidates(requestPath string, clean bool) []string {
+	if clean {
+		requestPath = path.Clean(requestPath)
+	}
+	trimmed := strings.TrimSuffix(requestPath, "/")
+	if trimmed == "" {
+		return []string{"/"}
+	}
+	return []string{trimmed, trimmed + "/"}
+}
+
+// has reports whether a handler is found for the given method and path.
+func (b *Blade) has(router *Router[Handler], method, path string) bool {
+	if router.Lookup(method, path, discardParameter) != nil {
+		return true
+	}
+	return method == http.MethodHead && b.autoHead && router.Lookup(http.MethodGet, path, discardParameter) != nil
+}
+
+// redirectPath redirects the request to the given path keeping the query.
+func (b *Blade) redirectPath(c *Context, path string) {
+	status := http.StatusPermanentRedirect
+	if method := c.request.Method(); method == http.MethodGet || method == http.MethodHead {
+		status = http.StatusMovedPermanently
+	}
+	u := url.URL{Path: path, RawQuery: c.request.req.URL.RawQuery}
+	c.Redirect(status, u.String())
+}
@@ -0,0 +1,168 @@
+package hblade
+
+import (
+	"cmp"
+	"encoding/xml"
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/goccy/go-json"
+	"github.com/goccy/go-yaml"
+	"github.com/pelletier/go-toml/v2"
+	"github.com/ugorji/go/codec"
+	"github.com/zatxm/hblade/v5/binding"
+	"google.golang.org/protobuf/proto"
+)
+
+// XML encodes the object to XML and responds.
+func (c *Context) XML(value any) error {
+	return c.render(contentTypeXML, xml.Marshal, value)
+}
+
+// YAML encodes the object to YAML and responds.
+func (c *Context) YAML(value any) error {
+	return c.render(contentTypeYAML, yaml.Marshal, value)
+}
+
+// TOML encodes the object to TOML and responds.
+func (c *Context) TOML(value any) error {
+	return c.render(contentTypeTOML, toml.Marshal, value)
+}
+
+// MsgPack encodes the object to MessagePack and responds.
+func (c *Context) MsgPack(value any) error {
+	return c.render(binding.MIMEMSGPACK, marshalMsgPack, value)
+}
+
+// ProtoBuf encodes the protocol buffers message and responds.
+func (c *Context) ProtoBuf(value any) error {
+	return c.render(binding.MIMEPROTOBUF, marshalProtoBuf, value)
+}
+
+// render encodes the object by marshal and responds with the content type.
+func (c *Context) render(contentType string, marshal func(any) ([]byte, error), value any) error {
+	bytes, err := marshal(value)
+	if err != nil {
+		return err
+	}
+
+	c.response.SetHeader(contentTypeHeader, contentType)
+	return c.Bytes(bytes)
+}
+
+func marshalMsgPack(value any) ([]byte, error) {
+	var bytes []byte
+	err := codec.NewEncoderBytes(&bytes, new(codec.MsgpackHandle)).Encode(value)
+	return bytes, err
+}
+
+func marshalProtoBuf(value any) ([]byte, error) {
+	msg, ok := value.(proto.Message)
+	if !ok {
+		return nil, errors.New("value is not ProtoMessage")
+	}
+	return proto.Marshal(msg)
+}
+
+// renderer encodes objects to a format.
+type renderer struct {
+	mediaTypes  []string
+	contentType string
+	marshal     func(any) ([]byte, error)
+}
+
+// renderers lists the formats Negotiate can respond with by preference.
+var renderers = [...]renderer{
+	{[]string{binding.MIMEJSON}, contentTypeJSON, json.Marshal},
+	{[]string{binding.MIMEXML, binding.MIMEXML2}, contentTypeXML, xml.Marshal},
+	{[]string{binding.MIMEYAML2, binding.MIMEYAML}, contentTypeYAML, yaml.Marshal},
+	{[]string{binding.MIMETOML}, contentTypeTOML, toml.Marshal},
+	{[]string{binding.MIMEMSGPACK2, binding.MIMEMSGPACK}, binding.MIMEMSGPACK, marshalMsgPack},
+	{[]string{binding.MIMEPROTOBUF}, binding.MIMEPROTOBUF, marshalProtoBuf},
+}
+
+// Negotiate responds with the object encoded to the format the Accept header
+// gives the highest quality: JSON, XML, YAML, TOML, MsgPack or ProtoBuf for proto messages.
+// A format the object can't be encoded to, e.g. XML for a map, falls back to the next
+// acceptable one. JSON is used without Accept header, 406 is sent when no acceptable
+// format can encode the object.
+func (c *Context) Negotiate(status int, value any) error {
+	addVary(c.response.rw.Header(), acceptHeader)
+	c.status = status
+
+	accept := c.request.Header(acceptHeader)
+	if accept == "" {
+		return c.JSON(value)
+	}
+
+	type candidate struct {
+		renderer *renderer
+		quality  float64
+	}
+	var candidates []candidate
+	for i := range renderers {
+		r := &renderers[i]
+		if _, ok := value.(proto.Message); !ok && r.contentType == binding.MIMEPROTOBUF {
+			continue
+		}
+
+		quality := 0.0
+		for _, mediaType := range r.mediaTypes {
+			quality = max(quality, mediaQuality(accept, mediaType))
+		}
+		if quality > 0 {
+			candidates = append(candidates, candidate{r, quality})
+		}
+	}
+	slices.SortStableFunc(candidates, func(a, b candidate) int {
+		return cmp.Compare(b.quality, a.quality)
+	})
+
+	for _, candidate := range candidates {
+		if bytes, err := candidate.renderer.marshal(value); err == nil {
+			c.response.SetHeader(contentTypeHeader, candidate.renderer.contentType)
+			return c.Bytes(bytes)
+		}
+	}
+
+	c.status = http.StatusNotAcceptable
+	return c.Text(http.StatusText(c.status))
+}
+
+// mediaQuality returns the quality between 0 and 1 the Accept header gives the media type,
+// the most specific matching range counts, e.g. text/html before text/* before */*.
+func mediaQuality(accept, mediaType string) float64 {
+	mainType, _, _ := strings.Cut(mediaType, "/")
+	quality, specificity := 0.0, -1
+
+	for part := range strings.SplitSeq(accept, ",") {
+		mediaRange, params, _ := strings.Cut(part, ";")
+		mediaRange = strings.ToLower(strings.TrimSpace(mediaRange))
+
+		s := -1
+		switch {
+		case mediaRange == mediaType:
+			s = 2
+		case mediaRange == mainType+"/*":
+			s = 1
+		case mediaRange == "*/*":
+			s = 0
+		}
+		if s <= specificity {
+			continue
+		}
+
+		q := 1.0
+		for param := range strings.SplitSeq(params, ";") {
+			key, value, _ := strings.Cut(param, "=")
+			if strings.EqualFold(strings.TrimSpace(key), "q") {
+				q, _ = strconv.ParseFloat(strings.TrimSpace(value), 64)
+			}
+		}
+		quality, specificity = q, s
+	}
+	return quality
+}
@@ -1,6 +1,9 @@
 package hblade
 
-import "net/http"
+import (
+	"net/http"
+	"strconv"
+)
 
 type Response interface {
 	Header(string) string
@@ -8,6 +11,7 @@ type Response interface {
 	SetHeader(string, string)
 	SetRw(http.ResponseWriter)
 	Pusher() http.Pusher
+	Flusher() http.Flusher
 }
 
 type response struct {
@@ -36,3 +40,59 @@ func (r *response) Pusher() (pusher http.Pusher) {
 	}
 	return nil
 }
+
+// Flusher returns the http.Flusher of the writer or the ones it wraps, nil if there is none.
+func (r *response) Flusher() http.Flusher {
+	w := r.rw
+	for {
+		if flusher, ok := w.(http.Flusher); ok {
+			return flusher
+		}
+		unwrapper, ok := w.(interface{ Unwrap() http.ResponseWriter })
+		if !ok {
+			return nil
+		}
+		w = unwrapper.Unwrap()
+	}
+}
+
+// headResponseWriter answers a HEAD request by a GET handler,
+// the body is discarded but its length is kept as Content-Length.
+type headResponseWriter struct {
+	http.ResponseWriter
+	status int
+	size   int
+}
+
+func (w *headResponseWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+}
+
+func (w *headResponseWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	w.size += len(b)
+	return len(b), nil
+}
+
+// Flush does nothing, the header is written by flush when the handler returned.
+func (w *headResponseWriter) Flush() {}
+
+func (w *headResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// flush writes the header with the counted Content-Length.
+func (w *headResponseWriter) flush() {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	header := w.ResponseWriter.Header()
+	if header.Get(contentLengthHeader) == "" && w.status != http.StatusNoContent && w.status != http.StatusNotModified {
+		header.Set(contentLengthHeader, strconv.Itoa(w.size))
+	}
+	w.ResponseWriter.WriteHeader(w.status)
+}
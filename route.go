@@ -0,0 +1,190 @@
+package hblade
+
+import (
+	"maps"
+	"net/url"
+	"strings"
+	"sync/atomic"
+
+	"github.com/pkg/errors"
+)
+
+// Route describes a registered route.
+type Route struct {
+	Host       string
+	Method     string
+	Path       string
+	Name       string
+	Handler    string
+	Middleware []string
+	metadata   *atomic.Pointer[map[string]any] // Replaced as a whole by SetMeta, a pointer to keep Route copyable
+	b          *Blade
+	handler    Handler
+}
+
+// Named sets the name the path of the route can be built with by Blade.URL.
+// It panics when the name is already used by another route.
+func (r *Route) Named(name string) *Route {
+	r.b.mu.Lock()
+	defer r.b.mu.Unlock()
+
+	if other, ok := r.b.names[name]; ok && other != r {
+		panic(errors.Errorf("route name '%s' of %s %s is already used by %s %s",
+			name, r.Method, r.Path, other.Method, other.Path))
+	}
+
+	if r.b.names == nil {
+		r.b.names = make(map[string]*Route)
+	}
+	delete(r.b.names, r.Name)
+	r.Name = name
+	r.b.names[name] = r
+	return r
+}
+
+// SetMeta attaches metadata to the route, like tags, a description or required scopes,
+// handlers and middleware read it by c.Route().Meta(key).
+func (r *Route) SetMeta(key string, value any) *Route {
+	r.b.mu.Lock()
+	defer r.b.mu.Unlock()
+
+	old := r.Metadata()
+	metadata := make(map[string]any, len(old)+1)
+	maps.Copy(metadata, old)
+	metadata[key] = value
+	r.metadata.Store(&metadata)
+	return r
+}
+
+// Meta returns the metadata of the route for the given key,
+// it's safe to call on the nil route of contexts without one.
+func (r *Route) Meta(key string) (any, bool) {
+	if r == nil {
+		return nil, false
+	}
+	value, ok := r.Metadata()[key]
+	return value, ok
+}
+
+// Metadata returns all metadata of the route, the map must not be modified.
+func (r *Route) Metadata() map[string]any {
+	if r == nil || r.metadata == nil {
+		return nil
+	}
+	if metadata := r.metadata.Load(); metadata != nil {
+		return *metadata
+	}
+	return nil
+}
+
+// serve handles the request by the handler of the route.
+func (r *Route) serve(c *Context) error {
+	c.route = r
+	return r.handler(c)
+}
+
+// Routes returns copies of the registered routes in the order they have been added,
+// with their metadata at the time of the call.
+func (b *Blade) Routes() []Route {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	routes := make([]Route, len(b.routes))
+	for i, r := range b.routes {
+		routes[i] = *r
+		routes[i].Middleware = append([]string(nil), r.Middleware...)
+		routes[i].metadata = new(atomic.Pointer[map[string]any])
+		routes[i].metadata.Store(r.metadata.Load())
+	}
+	return routes
+}
+
+// URL builds the path of the route with the given name,
+// params are pairs of parameter name and value, e.g.
+// b.URL("user", "id", "5") for /user/:id returns /user/5.
+func (b *Blade) URL(name string, params ...string) (string, error) {
+	b.mu.RLock()
+	r, ok := b.names[name]
+	b.mu.RUnlock()
+	if !ok {
+		return "", errors.Errorf("route name '%s' not found", name)
+	}
+	if len(params)%2 != 0 {
+		return "", errors.Errorf("odd number of params for route '%s'", name)
+	}
+	return buildPath(r.Path, params)
+}
+
+// newRoute describes the route of the given handler and middleware.
+func newRoute(b *Blade, method, path string, handler Handler, m []Middleware) *Route {
+	r := &Route{
+		Method:     method,
+		Path:       path,
+		Handler:    nameOfFunction(handler),
+		Middleware: make([]string, len(m)),
+		metadata:   new(atomic.Pointer[map[string]any]),
+		b:          b,
+	}
+	for i := range m {
+		r.Middleware[i] = nameOfFunction(m[i])
+	}
+	return r
+}
+
+// buildPath fills the parameters and wildcards of the path
+// with the escaped values of the given name/value pairs,
+// the values must match the constraints of their parameters.
+func buildPath(path string, params []string) (string, error) {
+	var sb strings.Builder
+	for {
+		start := strings.IndexAny(path, ":*")
+		if start == -1 {
+			sb.WriteString(path)
+			return sb.String(), nil
+		}
+		sb.WriteString(path[:start])
+
+		end := start + paramEnd(path[start:])
+		name, constraint := splitParam(path[start+1 : end])
+		value, ok := lookupParam(params, name)
+		if !ok && path[end-1] == optional {
+			// Only optional parameters follow, leave them out.
+			built := strings.TrimSuffix(sb.String(), "/")
+			if built == "" {
+				built = "/"
+			}
+			return built, nil
+		}
+		if !ok {
+			return "", errors.Errorf("missing value for parameter '%s'", name)
+		}
+		match, err := newConstraint(constraint)
+		if err != nil {
+			return "", err
+		}
+		if match != nil && !match(value) {
+			return "", errors.Errorf("value '%s' doesn't match the constraint '%s' of parameter '%s'", value, constraint, name)
+		}
+
+		if path[start] == wildcard {
+			segments := strings.Split(value, "/")
+			for i := range segments {
+				segments[i] = url.PathEscape(segments[i])
+			}
+			sb.WriteString(strings.Join(segments, "/"))
+		} else {
+			sb.WriteString(url.PathEscape(value))
+		}
+		path = path[end:]
+	}
+}
+
+// lookupParam returns the value for the given name of the name/value pairs.
+func lookupParam(params []string, name string) (string, bool) {
+	for i := 0; i+1 < len(params); i += 2 {
+		if params[i] == name {
+			return params[i+1], true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,97 @@
+package hblade
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"testing"
+)
+
+func TestURL(t *testing.T) {
+	app := New()
+	app.Get("/users/:id<int>", text("user")).Named("user")
+	app.Get("/archive/:year<int>/:month<int>?", text("archive")).Named("archive")
+	app.Get("/files/*path", text("file")).Named("file")
+	app.Get("/tags/:tag<[a-z]+>", text("tag")).Named("tag")
+
+	tests := []struct {
+		name   string
+		params []string
+		url    string
+		err    bool
+	}{
+		{"user", []string{"id", "5"}, "/users/5", false},
+		{"user", []string{"id", "abc"}, "", true},
+		{"user", nil, "", true},
+		{"archive", []string{"year", "2024"}, "/archive/2024", false},
+		{"archive", []string{"year", "2024", "month", "5"}, "/archive/2024/5", false},
+		{"archive", []string{"year", "2024", "month", "may"}, "", true},
+		{"file", []string{"path", "a b/c.png"}, "/files/a%20b/c.png", false},
+		{"tag", []string{"tag", "go"}, "/tags/go", false},
+		{"tag", []string{"tag", "Go"}, "", true},
+		{"missing", nil, "", true},
+		{"user", []string{"id"}, "", true},
+	}
+
+	for _, test := range tests {
+		url, err := app.URL(test.name, test.params...)
+		if url != test.url || (err != nil) != test.err {
+			t.Errorf("URL(%q, %q) = %q, %v, want %q", test.name, test.params, url, err, test.url)
+		}
+		if err == nil {
+			if rec := serve(app, http.MethodGet, url); rec.Code != http.StatusOK {
+				t.Errorf("GET %s = %d", url, rec.Code)
+			}
+		}
+	}
+}
+
+func TestReplaceKeepsName(t *testing.T) {
+	app := New()
+	app.Get("/users/:id", text("old")).Named("user").SetMeta("scope", "read")
+	app.Get("/posts/:id", text("post")).Named("post")
+
+	r := app.Replace(http.MethodGet, "/users/:id", text("new"))
+	if scope, _ := r.Meta("scope"); r.Name != "user" || scope != "read" {
+		t.Errorf("replacing route has name %q and scope %v", r.Name, scope)
+	}
+	if url, err := app.URL("user", "id", "5"); url != "/users/5" || err != nil {
+		t.Errorf("URL(user) = %q, %v", url, err)
+	}
+	if rec := serve(app, http.MethodGet, "/users/5"); rec.Body.String() != "new" {
+		t.Errorf("GET /users/5 = %q", rec.Body.String())
+	}
+
+	if !app.Remove(http.MethodGet, "/posts/:id") {
+		t.Fatal("Remove(/posts/:id) = false")
+	}
+	if _, err := app.URL("post", "id", "5"); err == nil {
+		t.Error("URL(post) of a removed route succeeded")
+	}
+}
+
+func TestRoutes(t *testing.T) {
+	app := New()
+	user := app.Get("/users/:id", text("user")).Named("user").SetMeta("scope", "read")
+	app.Post("/users", text("create"))
+
+	var listed []string
+	for _, r := range app.Routes() {
+		scope, _ := r.Meta("scope")
+		listed = append(listed, fmt.Sprint(r.Method, " ", r.Path, " ", r.Name, " ", scope))
+	}
+	want := []string{"GET /users/:id user read", "POST /users  <nil>"}
+	if !slices.Equal(listed, want) {
+		t.Errorf("Routes() = %q, want %q", listed, want)
+	}
+
+	// The copies keep the metadata at the time of the call.
+	routes := app.Routes()
+	user.SetMeta("scope", "admin")
+	if scope, _ := routes[0].Meta("scope"); scope != "read" {
+		t.Errorf("scope of the copy = %v, want read", scope)
+	}
+	if scope, _ := app.Routes()[0].Meta("scope"); scope != "admin" {
+		t.Errorf("scope = %v, want admin", scope)
+	}
+}
@@ -1,16 +1,45 @@
 package hblade
 
+import (
+	"maps"
+	"net/http"
+	"slices"
+
+	"github.com/pkg/errors"
+)
+
+// methods lists the standard HTTP methods the router keeps a fixed tree for.
+var methods = [...]string{
+	http.MethodGet,
+	http.MethodPost,
+	http.MethodDelete,
+	http.MethodPut,
+	http.MethodPatch,
+	http.MethodHead,
+	http.MethodConnect,
+	http.MethodTrace,
+	http.MethodOptions,
+}
+
+// MethodAny registers a route for every method, it's used for methods
+// without a route of their own, e.g. PROPFIND or PURGE with Any and Mount.
+const MethodAny = "*"
+
 // Router is a high-performance router.
 type Router[T any] struct {
-	get     Tree[T]
-	post    Tree[T]
-	delete  Tree[T]
-	put     Tree[T]
-	patch   Tree[T]
-	head    Tree[T]
-	connect Tree[T]
-	trace   Tree[T]
-	options Tree[T]
+	get       Tree[T]
+	post      Tree[T]
+	delete    Tree[T]
+	put       Tree[T]
+	patch     Tree[T]
+	head      Tree[T]
+	connect   Tree[T]
+	trace     Tree[T]
+	options   Tree[T]
+	any       Tree[T]
+	custom    map[string]*Tree[T]
+	maxParams int
+	hasAny    bool // Whether any holds routes, the other lookups fall back to it
 }
 
 // New creates a new router containing trees for every HTTP method.
@@ -19,19 +48,73 @@ func NewRouter[T any]() *Router[T] {
 }
 
 // Add registers a new handler for the given method and path.
-func (router *Router[T]) Add(method string, path string, handler T) {
+// It fails when the route conflicts with one already registered for the method.
+func (router *Router[T]) Add(method string, path string, handler T) error {
 	tree := router.selectTree(method)
-	tree.Add(path, handler)
+	if tree == nil {
+		tree = router.addTree(method)
+	}
+	if err := tree.Add(path, handler); err != nil {
+		return errors.Wrap(err, method)
+	}
+	router.maxParams = max(router.maxParams, countParams(path))
+	router.hasAny = router.hasAny || method == MethodAny
+	return nil
+}
+
+// MaxParams returns the maximum number of parameters of the registered routes.
+func (router *Router[T]) MaxParams() int {
+	return router.maxParams
 }
 
 // LookupNoAlloc finds the handler and parameters for the given route without using any memory allocations.
+// Routes of MethodAny are found when the method has no route for the path.
 func (router *Router[T]) Lookup(method string, path string, addParameter func(string, string)) T {
-	tree := router.selectTree(method)
-	if tree == nil {
+	if tree := router.selectTree(method); tree != nil {
+		if data, ok := tree.lookup(path, addParameter); ok {
+			return data
+		}
+	}
+	if !router.hasAny {
 		var empty T
 		return empty
 	}
-	return tree.Lookup(path, addParameter)
+	return router.any.Lookup(path, addParameter)
+}
+
+// FindCaseInsensitive returns the path of the route for the given method
+// matching the given path case-insensitively, written with the case of the route.
+func (router *Router[T]) FindCaseInsensitive(method string, path string) (string, bool) {
+	if tree := router.selectTree(method); tree != nil {
+		if fixed, ok := tree.FindCaseInsensitive(path); ok {
+			return fixed, true
+		}
+	}
+	if !router.hasAny {
+		return "", false
+	}
+	return router.any.FindCaseInsensitive(path)
+}
+
+// Allowed returns the methods, except the given one,
+// which have a route registered for the given path.
+func (router *Router[T]) Allowed(method string, path string) []string {
+	var allowed []string
+	for _, m := range methods {
+		if m == method {
+			continue
+		}
+		if router.selectTree(m).Has(path) {
+			allowed = append(allowed, m)
+		}
+	}
+
+	for _, m := range slices.Sorted(maps.Keys(router.custom)) {
+		if m != method && router.custom[m].Has(path) {
+			allowed = append(allowed, m)
+		}
+	}
+	return allowed
 }
 
 // selectTree returns the tree by the given HTTP method.
@@ -55,7 +138,19 @@ func (router *Router[T]) selectTree(method string) *Tree[T] {
 		return &router.trace
 	case "OPTIONS":
 		return &router.options
+	case MethodAny:
+		return &router.any
 	default:
-		return nil
+		return router.custom[method]
+	}
+}
+
+// addTree creates the tree for a method without a fixed tree, like PROPFIND or PURGE.
+func (router *Router[T]) addTree(method string) *Tree[T] {
+	if router.custom == nil {
+		router.custom = make(map[string]*Tree[T])
 	}
+	tree := &Tree[T]{}
+	router.custom[method] = tree
+	return tree
 }
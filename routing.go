@@ -0,0 +1,121 @@
+package hblade
+
+import (
+	"slices"
+	"strings"
+)
+
+// routing holds the routers requests are served by.
+// Once requests are served it's never modified but replaced as a whole.
+type routing struct {
+	router    *Router[Handler]
+	hosts     []*host
+	maxParams int // Of a route including its host
+}
+
+// add adds the route to the router of its host.
+func (rt *routing) add(r *Route) error {
+	router, params := rt.router, 0
+	if r.Host != "" {
+		h := rt.host(r.Host)
+		router, params = h.router, h.params
+	}
+	if err := router.Add(r.Method, r.Path, r.serve); err != nil {
+		return err
+	}
+	rt.maxParams = max(rt.maxParams, params+router.MaxParams())
+	return nil
+}
+
+// host returns the host with the given pattern or nil.
+func (rt *routing) host(pattern string) *host {
+	for _, h := range rt.hosts {
+		if h.pattern == pattern {
+			return h
+		}
+	}
+	return nil
+}
+
+// Replace registers a new handler for the given method and path,
+// replacing the route registered for them before, while serving requests.
+// The new route keeps the name and metadata of the replaced one.
+func (b *Blade) Replace(method, path string, handler Handler, m ...Middleware) *Route {
+	return b.add("", method, path, handler, m, true)
+}
+
+// Remove removes the route registered for the given method and path,
+// it reports whether there was one. Requests being served aren't affected.
+func (b *Blade) Remove(method, path string) bool {
+	return b.remove("", method, path)
+}
+
+// remove removes the route of the host from the served ones.
+func (b *Blade) remove(hostname, method, path string) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	path = "/" + strings.Trim(path, "/")
+	routes := slices.DeleteFunc(slices.Clone(b.routes), func(r *Route) bool {
+		return r.Host == hostname && r.Method == method && r.Path == path
+	})
+	if len(routes) == len(b.routes) {
+		return false
+	}
+
+	next, err := b.build(routes)
+	if err != nil {
+		panic(err)
+	}
+	b.commit(routes, next)
+	return true
+}
+
+// build creates the routing for the given routes and the hosts known so far.
+func (b *Blade) build(routes []*Route) (*routing, error) {
+	current := b.routing.Load()
+	next := &routing{
+		router: &Router[Handler]{},
+		hosts:  make([]*host, len(current.hosts)),
+	}
+	for i, h := range current.hosts {
+		next.hosts[i] = &host{pattern: h.pattern, params: h.params, router: &Router[Handler]{}}
+	}
+
+	for _, r := range routes {
+		if err := next.add(r); err != nil {
+			return nil, err
+		}
+	}
+	return next, nil
+}
+
+// commit makes the given routes and their routing the served ones.
+// The names of removed routes are dropped unless a replacing route took them over.
+func (b *Blade) commit(routes []*Route, next *routing) {
+	for name, r := range b.names {
+		if slices.Contains(routes, r) {
+			continue
+		}
+		if i := slices.IndexFunc(routes, func(r *Route) bool { return r.Name == name }); i != -1 {
+			b.names[name] = routes[i]
+		} else {
+			delete(b.names, name)
+		}
+	}
+	b.routes = routes
+	b.routing.Store(next)
+}
+
+// startServing switches route changes to replacing the routing
+// instead of modifying it, it's called before the first request is served.
+func (b *Blade) startServing() {
+	b.mu.Lock()
+	b.serving.Store(true)
+	b.mu.Unlock()
+}
+
+// Router returns the router used by the blade for the default host.
+func (b *Blade) Router() *Router[Handler] {
+	return b.routing.Load().router
+}
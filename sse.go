@@ -0,0 +1,131 @@
+package hblade
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Event is a server-sent event, empty fields other than Data aren't sent.
+type Event struct {
+	ID    string
+	Event string
+	Data  string
+	Retry time.Duration
+}
+
+// EventStream sends server-sent events to the client.
+type EventStream struct {
+	c *Context
+}
+
+// SSE starts a server-sent event stream, the headers are sent right away.
+//
+//	s, err := c.SSE()
+//	if err != nil {
+//		return err
+//	}
+//	return s.Stream(events, 15*time.Second)
+func (c *Context) SSE() (*EventStream, error) {
+	header := c.response.rw.Header()
+	header.Set(contentTypeHeader, contentTypeEventStream)
+	header.Set(cacheControlHeader, cacheControlNoCache)
+	header.Set("Connection", "keep-alive")
+	header.Set("X-Accel-Buffering", "no")
+
+	c.status = http.StatusOK
+	c.response.rw.WriteHeader(c.status)
+	if err := c.Flush(); err != nil {
+		return nil, err
+	}
+	return &EventStream{c: c}, nil
+}
+
+// LastEventID returns the ID of the last event the client received before reconnecting.
+func (s *EventStream) LastEventID() string {
+	return s.c.request.Header(lastEventIDHeader)
+}
+
+// Send sends the event and flushes it to the client.
+func (s *EventStream) Send(e Event) error {
+	if err := s.c.request.Context().Err(); err != nil {
+		return err
+	}
+
+	var sb strings.Builder
+	if e.ID != "" {
+		sb.WriteString("id: " + singleLine(e.ID) + "\n")
+	}
+	if e.Event != "" {
+		sb.WriteString("event: " + singleLine(e.Event) + "\n")
+	}
+	if e.Retry > 0 {
+		// The client reads milliseconds, shorter durations would disable retrying.
+		retry := max(e.Retry.Milliseconds(), 1)
+		sb.WriteString("retry: " + strconv.FormatInt(retry, 10) + "\n")
+	}
+	// At least one data line is sent, the client drops events without data.
+	for _, line := range strings.Split(lineBreaks.Replace(e.Data), "\n") {
+		sb.WriteString("data: " + line + "\n")
+	}
+	sb.WriteString("\n")
+	return s.write(sb.String())
+}
+
+// Comment sends a comment the client ignores, e.g. as heartbeat.
+func (s *EventStream) Comment(text string) error {
+	return s.write(": " + singleLine(text) + "\n\n")
+}
+
+// Stream sends the events until the channel is closed or the request is canceled,
+// a comment is sent as heartbeat when no event was sent for the given duration if not 0.
+func (s *EventStream) Stream(events <-chan Event, heartbeat time.Duration) error {
+	var (
+		ticker *time.Ticker
+		tick   <-chan time.Time
+	)
+	if heartbeat > 0 {
+		ticker = time.NewTicker(heartbeat)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	done := s.c.request.Context().Done()
+	for {
+		select {
+		case <-done:
+			return nil
+		case e, ok := <-events:
+			if !ok {
+				return nil
+			}
+			if err := s.Send(e); err != nil {
+				return err
+			}
+			if ticker != nil {
+				ticker.Reset(heartbeat)
+			}
+		case <-tick:
+			if err := s.Comment("heartbeat"); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// write writes the text and flushes it to the client.
+func (s *EventStream) write(text string) error {
+	if _, err := s.c.response.rw.Write([]byte(text)); err != nil {
+		return err
+	}
+	return s.c.Flush()
+}
+
+// lineBreaks turns the line breaks ending a field, CRLF, CR and LF, into LF.
+var lineBreaks = strings.NewReplacer("\r\n", "\n", "\r", "\n")
+
+// singleLine returns the text as a single line.
+func singleLine(s string) string {
+	return strings.ReplaceAll(lineBreaks.Replace(s), "\n", " ")
+}
@@ -0,0 +1,39 @@
+package hblade
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestEventStreamSend(t *testing.T) {
+	tests := []struct {
+		event Event
+		sent  string
+	}{
+		{Event{Data: "hello"}, "data: hello\n\n"},
+		{Event{Event: "ping"}, "event: ping\ndata: \n\n"},
+		{Event{Data: "a\nb\n"}, "data: a\ndata: b\ndata: \n\n"},
+		{Event{Data: "a\r\nb\rc"}, "data: a\ndata: b\ndata: c\n\n"},
+		{Event{ID: "1\n2", Data: "x", Retry: time.Microsecond}, "id: 1 2\nretry: 1\ndata: x\n\n"},
+	}
+
+	for _, test := range tests {
+		app := New()
+		app.Get("/events", func(c *Context) error {
+			s, err := c.SSE()
+			if err != nil {
+				return err
+			}
+			return s.Send(test.event)
+		})
+
+		rec := serve(app, http.MethodGet, "/events")
+		if rec.Code != http.StatusOK || rec.Header().Get(contentTypeHeader) != contentTypeEventStream {
+			t.Errorf("%+v: %d %q", test.event, rec.Code, rec.Header().Get(contentTypeHeader))
+		}
+		if sent := rec.Body.String(); sent != test.sent {
+			t.Errorf("%+v sent %q, want %q", test.event, sent, test.sent)
+		}
+	}
+}
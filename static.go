@@ -0,0 +1,229 @@
+package hblade
+
+import (
+	"bytes"
+	"html"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"slices"
+	"strings"
+)
+
+// StaticConfig configures serving files by StaticFS.
+type StaticConfig struct {
+	// Index lists the files served for a directory, index.html if empty.
+	Index []string
+	// Browse lists the entries of directories without an index file.
+	Browse bool
+	// CacheControl is the Cache-Control header of the files,
+	// media files are cached for 160 days if empty.
+	CacheControl string
+	// Fallback is the file served for unknown paths without extension,
+	// e.g. index.html of a single-page app. HTML files are then sent with no-cache
+	// and hashed assets like app.3f2a9c1b.js as immutable.
+	Fallback string
+	// Exclude lists patterns of request paths not falling back, e.g. /app/api/*.
+	// They are matched by path.Match, a trailing /* matches all paths below.
+	Exclude []string
+}
+
+// StaticFS serves the files of fsys, e.g. an embed.FS, under the prefix.
+//
+//	//go:embed public
+//	var public embed.FS
+//
+//	sub, _ := fs.Sub(public, "public")
+//	h.StaticFS("/static", sub, hblade.StaticConfig{})
+func (b *Blade) StaticFS(prefix string, fsys fs.FS, config StaticConfig, m ...Middleware) *Route {
+	return b.Get("/"+strings.Trim(prefix, "/")+"/*file?", staticHandler(fsys, config), m...)
+}
+
+// staticHandler returns the handler serving the files of fsys by the file parameter.
+func staticHandler(fsys fs.FS, config StaticConfig) Handler {
+	if len(config.Index) == 0 {
+		config.Index = []string{"index.html"}
+	}
+
+	return func(c *Context) error {
+		name := strings.TrimPrefix(path.Clean("/"+c.Get("file")), "/")
+		if name == "" {
+			name = "."
+		}
+
+		info, err := fs.Stat(fsys, name)
+		if err != nil {
+			if config.fallback(c.request.Path()) {
+				return serveFS(c, fsys, config.Fallback, config)
+			}
+			c.b.notFound(c)
+			return nil
+		}
+		if !info.IsDir() {
+			return serveFS(c, fsys, name, config)
+		}
+
+		// Relative links of the directory need the trailing slash.
+		if p := c.request.Path(); !strings.HasSuffix(p, "/") {
+			u := url.URL{Path: path.Base(p) + "/", RawQuery: c.request.req.URL.RawQuery}
+			return c.Redirect(http.StatusMovedPermanently, u.String())
+		}
+
+		for _, index := range config.Index {
+			if info, err := fs.Stat(fsys, path.Join(name, index)); err == nil && !info.IsDir() {
+				return serveFS(c, fsys, path.Join(name, index), config)
+			}
+		}
+
+		if config.Browse {
+			return listDir(c, fsys, name)
+		}
+		if config.fallback(c.request.Path()) {
+			return serveFS(c, fsys, config.Fallback, config)
+		}
+		c.b.notFound(c)
+		return nil
+	}
+}
+
+// fallback reports whether the fallback file is served for the request path.
+func (config *StaticConfig) fallback(requestPath string) bool {
+	if config.Fallback == "" || path.Ext(requestPath) != "" {
+		return false
+	}
+	for _, pattern := range config.Exclude {
+		if below, ok := strings.CutSuffix(pattern, "/*"); ok && (requestPath == below || strings.HasPrefix(requestPath, below+"/")) {
+			return false
+		}
+		if ok, _ := path.Match(pattern, requestPath); ok {
+			return false
+		}
+	}
+	return true
+}
+
+// isHashed reports whether the file name contains a content hash
+// of at least 8 letters and digits, e.g. app.3f2a9c1b.js or index-BqU3K9aA.js.
+func isHashed(name string) bool {
+	base := strings.TrimSuffix(path.Base(name), path.Ext(name))
+	hash := base[strings.LastIndexAny(base, ".-")+1:]
+	if len(hash) < 8 || len(hash) == len(base) {
+		return false
+	}
+
+	digits := 0
+	for i := 0; i < len(hash); i++ {
+		if !isNameChar(hash[i]) {
+			return false
+		}
+		if '0' <= hash[i] && hash[i] <= '9' {
+			digits++
+		}
+	}
+	return digits > 0
+}
+
+// serveFS sends the file of fsys with support for Range and conditional requests.
+func serveFS(c *Context, fsys fs.FS, name string, config StaticConfig) error {
+	contentType := mime.TypeByExtension(path.Ext(name))
+	switch {
+	case config.Fallback != "" && strings.HasPrefix(contentType, "text/html"):
+		c.response.SetHeader(cacheControlHeader, cacheControlNoCache)
+	case config.Fallback != "" && isHashed(name):
+		c.response.SetHeader(cacheControlHeader, cacheControlImmutable)
+	case config.CacheControl != "":
+		c.response.SetHeader(cacheControlHeader, config.CacheControl)
+	case isMedia(contentType):
+		c.response.SetHeader(cacheControlHeader, cacheControlMedia)
+	}
+
+	var f fs.File
+	if c.b.precompress && contentType != "" {
+		stat := func(name string) (fs.FileInfo, error) { return fs.Stat(fsys, name) }
+		if encoded, encoding := c.precompressed(name, stat); encoded != "" {
+			if file, err := fsys.Open(encoded); err == nil {
+				c.response.SetHeader(contentTypeHeader, contentType)
+				c.response.SetHeader(contentEncodingHeader, encoding)
+				f = file
+			}
+		}
+	}
+
+	if f == nil {
+		file, err := fsys.Open(name)
+		if err != nil {
+			c.b.notFound(c)
+			return nil
+		}
+		f = file
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	content, ok := f.(io.ReadSeeker)
+	if !ok {
+		data, err := io.ReadAll(f)
+		if err != nil {
+			return err
+		}
+		content = bytes.NewReader(data)
+	}
+
+	http.ServeContent(c.response.rw, c.request.req, info.Name(), info.ModTime(), content)
+	return nil
+}
+
+// listDir sends a HTML list of the directory entries.
+func listDir(c *Context, fsys fs.FS, name string) error {
+	entries, err := fs.ReadDir(fsys, name)
+	if err != nil {
+		return err
+	}
+	slices.SortFunc(entries, func(a, b fs.DirEntry) int { return strings.Compare(a.Name(), b.Name()) })
+
+	var sb strings.Builder
+	sb.WriteString("<!doctype html>\n<meta name=\"viewport\" content=\"width=device-width\">\n<pre>\n")
+	for _, entry := range entries {
+		entryName := entry.Name()
+		if entry.IsDir() {
+			entryName += "/"
+		}
+		u := url.URL{Path: entryName}
+		sb.WriteString("<a href=\"" + html.EscapeString(u.String()) + "\">" + html.EscapeString(entryName) + "</a>\n")
+	}
+	sb.WriteString("</pre>\n")
+	return c.HTML(sb.String())
+}
+
+// precompressedFiles lists the extensions of precompressed files by preference.
+var precompressedFiles = [...]struct{ extension, encoding string }{
+	{".br", contentEncodingBrotli},
+	{".gz", contentEncodingGzip},
+}
+
+// precompressed returns the name and content coding of the precompressed variant
+// of the file the client accepts best or empty strings. Vary is set when there is any variant,
+// Content-Encoding is left to the caller once the variant has been opened.
+func (c *Context) precompressed(name string, stat func(string) (fs.FileInfo, error)) (string, string) {
+	acceptEncoding := c.request.Header(acceptEncodingHeader)
+	encoded, encoding, quality := "", "", 0.0
+	for _, p := range precompressedFiles {
+		info, err := stat(name + p.extension)
+		if err != nil || info.IsDir() {
+			continue
+		}
+
+		addVary(c.response.rw.Header(), acceptEncodingHeader)
+		if q := encodingQuality(acceptEncoding, p.encoding); q > quality {
+			encoded, encoding, quality = name+p.extension, p.encoding, q
+		}
+	}
+	return encoded, encoding
+}
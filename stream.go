@@ -0,0 +1,70 @@
+package hblade
+
+import (
+	"io"
+	"net/http"
+)
+
+// Flush sends the data written so far to the client.
+func (c *Context) Flush() error {
+	flusher := c.response.Flusher()
+	if flusher == nil {
+		return http.ErrNotSupported
+	}
+	flusher.Flush()
+	return nil
+}
+
+// ClientGone reports whether the client closed the connection or the request was canceled.
+func (c *Context) ClientGone() bool {
+	return c.request.Context().Err() != nil
+}
+
+// Stream calls step and flushes what it wrote until it returns false, the client is gone
+// or a write fails. Nothing is buffered, e.g. for NDJSON results or progress:
+//
+//	return c.Stream(func(w io.Writer) bool {
+//		row, ok := <-rows
+//		if ok {
+//			json.NewEncoder(w).Encode(row)
+//		}
+//		return ok
+//	})
+func (c *Context) Stream(step func(w io.Writer) bool) error {
+	if c.response.Flusher() == nil {
+		return http.ErrNotSupported
+	}
+
+	c.response.rw.WriteHeader(c.status)
+	w := &streamWriter{w: c.response.rw}
+	for !c.ClientGone() {
+		open := step(w)
+		if w.err != nil {
+			break
+		}
+		if err := c.Flush(); err != nil || !open {
+			return err
+		}
+	}
+
+	// Write errors are expected once the client is gone.
+	if c.ClientGone() {
+		return nil
+	}
+	return w.err
+}
+
+// streamWriter keeps the first error writing a stream.
+type streamWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (w *streamWriter) Write(b []byte) (int, error) {
+	if w.err != nil {
+		return 0, w.err
+	}
+	n, err := w.w.Write(b)
+	w.err = err
+	return n, err
+}
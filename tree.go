@@ -1,12 +1,47 @@
 package hblade
 
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
 // Tree represents a radix tree.
 type Tree[T any] struct {
-	root treeNode[T]
+	root       treeNode[T]
+	static     map[string]T // Routes without parameters, checked before the nodes
+	staticEnds []uint64     // Per length of the routes in static, their bits of staticBit
 }
 
 // Add adds a new element to the tree.
-func (tree *Tree[T]) Add(path string, data T) {
+// It fails when the path conflicts with a route that has already been added.
+func (tree *Tree[T]) Add(path string, data T) error {
+	if err := validatePath(path); err != nil {
+		return err
+	}
+
+	for _, p := range expandOptional(path) {
+		if err := tree.add(p, data, path); err != nil {
+			return err
+		}
+		if !strings.ContainsAny(p, ":*") {
+			if tree.static == nil {
+				tree.static = make(map[string]T)
+			}
+			tree.static[p] = data
+			n := len(p)
+			if n >= len(tree.staticEnds) {
+				tree.staticEnds = append(tree.staticEnds, make([]uint64, n+1-len(tree.staticEnds))...)
+			}
+			tree.staticEnds[n] |= staticBit(p)
+		}
+	}
+	return nil
+}
+
+// add adds a path without optional parameters to the tree,
+// route is the path as registered and reported by conflicts.
+func (tree *Tree[T]) add(path string, data T, route string) error {
 	// Search tree for equal parts until we can no longer proceed
 	i := 0
 	offset := 0
@@ -16,18 +51,38 @@ func (tree *Tree[T]) Add(path string, data T) {
 	begin:
 		switch node.kind {
 		case parameter:
-			// This only occurs when the same parameter based route is added twice.
+			// The parameter has already been compared by end.
+			// node: /post/:id<int>|
+			// path: /post/:id<int>|/edit
+			i = offset + paramEnd(path[offset:])
+
+			// The path ends with the parameter.
 			// node: /post/:id|
 			// path: /post/:id|
 			if i == len(path) {
+				if node.route != "" {
+					return errors.Errorf("route '%s' conflicts with existing route '%s'", route, node.route)
+				}
 				node.data = data
-				return
+				node.route = route
+				return nil
 			}
 
-			// When we hit a separator, we'll search for a fitting child.
-			if path[i] == separator {
-				node, offset, _ = node.end(path, data, i, offset)
-				goto next
+			// Search for a fitting child.
+			var (
+				control flow
+				err     error
+			)
+			node, offset, control, err = node.end(path, data, i, offset, route)
+			if err != nil {
+				return err
+			}
+
+			switch control {
+			case flowStop:
+				return nil
+			case flowBegin:
+				goto begin
 			}
 
 		default:
@@ -36,27 +91,36 @@ func (tree *Tree[T]) Add(path string, data T) {
 				// node: /blog|
 				// path: /blog|
 				if i-offset == len(node.prefix) {
+					if node.route != "" {
+						return errors.Errorf("route '%s' conflicts with existing route '%s'", route, node.route)
+					}
 					node.data = data
-					return
+					node.route = route
+					return nil
 				}
 
 				// The path ended but the node prefix is longer.
 				// node: /blog|feed
 				// path: /blog|
-				node.split(i-offset, "", data)
-				return
+				return node.split(i-offset, "", data, route)
 			}
 
 			// The node we just checked is entirely included in our path.
 			// node: /|
 			// path: /|blog
 			if i-offset == len(node.prefix) {
-				var control flow
-				node, offset, control = node.end(path, data, i, offset)
+				var (
+					control flow
+					err     error
+				)
+				node, offset, control, err = node.end(path, data, i, offset, route)
+				if err != nil {
+					return err
+				}
 
 				switch control {
 				case flowStop:
-					return
+					return nil
 				case flowBegin:
 					goto begin
 				case flowNext:
@@ -68,8 +132,7 @@ func (tree *Tree[T]) Add(path string, data T) {
 			// node: /b|ag
 			// path: /b|riefcase
 			if path[i] != node.prefix[i-offset] {
-				node.split(i-offset, path[i:], data)
-				return
+				return node.split(i-offset, path[i:], data, route)
 			}
 		}
 
@@ -78,109 +141,100 @@ func (tree *Tree[T]) Add(path string, data T) {
 	}
 }
 
-// Lookup finds the data for the given path without using any memory allocations.
-func (tree *Tree[T]) Lookup(path string, addParameter func(key string, value string)) T {
-	var (
-		i             uint
-		parameterPath string
-		wildcardPath  string
-		parameter     *treeNode[T]
-		wildcard      *treeNode[T]
-		node          = &tree.root
-	)
-
-	// Skip the first loop iteration if the starting characters are equal
-	if len(path) > 0 && len(node.prefix) > 0 && path[0] == node.prefix[0] {
-		i = 1
-	}
-
-begin:
-	// Search tree for equal parts until we can no longer proceed
-	for i < uint(len(path)) {
-		// The node we just checked is entirely included in our path.
-		// node: /|
-		// path: /|blog
-		if i == uint(len(node.prefix)) {
-			if node.wildcard != nil {
-				wildcard = node.wildcard
-				wildcardPath = path[i:]
-			}
-
-			parameter = node.parameter
-			parameterPath = path[i:]
-			char := path[i]
-
-			if char >= node.startIndex && char < node.endIndex {
-				index := node.indices[char-node.startIndex]
+// validatePath checks the parameters and wildcards of the given path.
+func validatePath(path string) error {
+	optionalStart := -1
 
-				if index != 0 {
-					node = node.children[index]
-					path = path[i:]
-					i = 1
-					continue
-				}
-			}
-
-			// node: /|:id
-			// path: /|blog
-			if node.parameter != nil {
-				node = node.parameter
-				path = path[i:]
-				i = 1
-
-				for i < uint(len(path)) {
-					// node: /:id|/posts
-					// path: /123|/posts
-					if path[i] == separator {
-						addParameter(node.prefix, path[:i])
-						index := node.indices[separator-node.startIndex]
-						node = node.children[index]
-						path = path[i:]
-						i = 1
-						goto begin
-					}
-
-					i++
-				}
-
-				addParameter(node.prefix, path[:i])
-				return node.data
+	for offset := 0; ; {
+		start := strings.IndexAny(path[offset:], ":*")
+		if start == -1 {
+			if optionalStart != -1 && offset != len(path) {
+				return errors.Errorf("optional '%s' must be followed by optional parameters only in route '%s'", path[optionalStart:], path)
 			}
+			return nil
+		}
 
-			// node: /|*any
-			// path: /|image.png
-			goto notFound
+		start += offset
+		end := start + paramEnd(path[start:])
+		token := path[start:end]
+		name, constraint := splitParam(token[1:])
+		isOptional := token[len(token)-1] == optional
+
+		switch {
+		case name == "":
+			return errors.Errorf("missing name for '%s' in route '%s'", token, path)
+		case strings.IndexByte(token, constraintStart) != -1 && !strings.HasSuffix(strings.TrimSuffix(token, string(optional)), string(constraintEnd)):
+			return errors.Errorf("unclosed constraint in '%s' of route '%s'", token, path)
+		case path[start] == wildcard && end != len(path):
+			return errors.Errorf("wildcard '%s' must be at the end of route '%s'", token, path)
+		case path[start] == wildcard && constraint != "":
+			return errors.Errorf("wildcard '%s' can't have a constraint in route '%s'", token, path)
+		case end != len(path) && (path[end] == parameter || path[end] == wildcard):
+			return errors.Errorf("parameter '%s' must be followed by static text in route '%s'", token, path)
+		case isOptional && (start == 0 || path[start-1] != separator || (end != len(path) && path[end] != separator)):
+			return errors.Errorf("optional '%s' must be a whole segment in route '%s'", token, path)
+		case optionalStart != -1 && (!isOptional || start != offset+1):
+			return errors.Errorf("optional '%s' must be followed by optional parameters only in route '%s'", path[optionalStart:], path)
 		}
 
-		// We got a conflict.
-		// node: /b|ag
-		// path: /b|riefcase
-		if path[i] != node.prefix[i] {
-			goto notFound
+		if isOptional && optionalStart == -1 {
+			optionalStart = start
 		}
 
-		i++
+		if _, err := newConstraint(constraint); err != nil {
+			return errors.Wrapf(err, "route '%s'", path)
+		}
+		offset = end
 	}
+}
 
-	// node: /blog|
-	// path: /blog|
-	if i == uint(len(node.prefix)) {
-		return node.data
-	}
+// Lookup finds the data for the given path without using any memory allocations.
+// Static routes are found in a map, the nodes are only walked for the others.
+// Paths no static route has the length and last byte of skip the map, hashing them would only cost.
+func (tree *Tree[T]) Lookup(path string, addParameter func(key string, value string)) T {
+	data, _ := tree.lookup(path, addParameter)
+	return data
+}
 
-	// node: /|*any
-	// path: /|image.png
-notFound:
-	if parameter != nil {
-		addParameter(parameter.prefix, parameterPath)
-		return parameter.data
+// lookup finds the data for the given path and reports whether there is a route.
+func (tree *Tree[T]) lookup(path string, addParameter func(key string, value string)) (T, bool) {
+	if n := len(path); n > 0 && n < len(tree.staticEnds) && tree.staticEnds[n]&staticBit(path) != 0 {
+		if data, ok := tree.static[path]; ok {
+			return data, true
+		}
 	}
 
-	if wildcard != nil {
-		addParameter(wildcard.prefix, wildcardPath)
-		return wildcard.data
+	node := tree.find(path, addParameter)
+	if node == nil {
+		var empty T
+		return empty, false
 	}
+	return node.data, true
+}
+
+// Has reports whether a route has been registered for the given path.
+func (tree *Tree[T]) Has(path string) bool {
+	_, ok := tree.lookup(path, discardParameter)
+	return ok
+}
+
+// FindCaseInsensitive returns the path of the route matching the given path case-insensitively,
+// written with the case of the route.
+func (tree *Tree[T]) FindCaseInsensitive(path string) (string, bool) {
+	buf, ok := tree.root.findFold(path, make([]byte, 0, len(path)))
+	return string(buf), ok
+}
 
-	var empty T
-	return empty
+// find returns the node of the route matching the given path or nil if there is none.
+func (tree *Tree[T]) find(path string, addParameter func(key string, value string)) *treeNode[T] {
+	return tree.root.findLinear(path, addParameter)
 }
+
+// staticBit returns the bit of the path in the filter of the static routes of its length,
+// it mixes the last and the middle byte.
+func staticBit(path string) uint64 {
+	return 1 << ((path[len(path)-1]*31 + path[len(path)/2]) % 64)
+}
+
+// discardParameter is used for lookups that don't need the parameters.
+func discardParameter(string, string) {}
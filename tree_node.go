@@ -1,6 +1,10 @@
 package hblade
 
-import "strings"
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+)
 
 // node types
 const (
@@ -10,23 +14,29 @@ const (
 )
 
 // treeNode represents a radix tree node.
+// The fields read by every lookup come first to share the cache lines.
 type treeNode[T any] struct {
 	prefix     string
-	data       T
+	indices    []uint8
 	children   []*treeNode[T]
 	parameter  *treeNode[T]
 	wildcard   *treeNode[T]
-	indices    []uint8
 	startIndex uint8
 	endIndex   uint8
 	kind       byte
+	next       *treeNode[T]
+	match      func(string) bool
+	route      string
+	data       T
+	constraint string
+	fallback   bool // Whether findLinear leaves the parameter to findChild, see updateFallback
 }
 
 // split splits the node at the given index and inserts
 // a new child node with the given path and data.
 // If path is empty, it will not create another child node
 // and instead assign the data directly to the node.
-func (node *treeNode[T]) split(index int, path string, data T) {
+func (node *treeNode[T]) split(index int, path string, data T, route string) error {
 	// Create split node with the remaining string
 	splitNode := node.clone(node.prefix[index:])
 
@@ -37,14 +47,15 @@ func (node *treeNode[T]) split(index int, path string, data T) {
 	// Just assign the data for the existing node and store a single child node.
 	if path == "" {
 		node.data = data
+		node.route = route
 		node.addChild(splitNode)
-		return
+		return nil
 	}
 
 	node.addChild(splitNode)
 
 	// Create new nodes with the remaining path
-	node.append(path, data)
+	return node.append(path, data, route)
 }
 
 // clone clones the node with a new prefix.
@@ -52,6 +63,7 @@ func (node *treeNode[T]) clone(prefix string) *treeNode[T] {
 	return &treeNode[T]{
 		prefix:     prefix,
 		data:       node.data,
+		route:      node.route,
 		indices:    node.indices,
 		startIndex: node.startIndex,
 		endIndex:   node.endIndex,
@@ -67,6 +79,7 @@ func (node *treeNode[T]) reset(prefix string) {
 	var empty T
 	node.prefix = prefix
 	node.data = empty
+	node.route = ""
 	node.parameter = nil
 	node.wildcard = nil
 	node.kind = 0
@@ -111,26 +124,14 @@ func (node *treeNode[T]) addChild(child *treeNode[T]) {
 	if index == 0 {
 		node.indices[firstChar-node.startIndex] = uint8(len(node.children))
 		node.children = append(node.children, child)
-		return
-	}
-
-	node.children[index] = child
-}
-
-// addTrailingSlash adds a trailing slash with the same data.
-func (node *treeNode[T]) addTrailingSlash(data T) {
-	if strings.HasSuffix(node.prefix, "/") || node.kind == wildcard || (separator >= node.startIndex && separator < node.endIndex && node.indices[separator-node.startIndex] != 0) {
-		return
+	} else {
+		node.children[index] = child
 	}
-
-	node.addChild(&treeNode[T]{
-		prefix: "/",
-		data:   data,
-	})
+	node.updateFallback()
 }
 
 // append appends the given path to the tree.
-func (node *treeNode[T]) append(path string, data T) {
+func (node *treeNode[T]) append(path string, data T, route string) error {
 	// At this point, all we know is that somewhere
 	// in the remaining string we have parameters.
 	// node: /user|
@@ -138,14 +139,11 @@ func (node *treeNode[T]) append(path string, data T) {
 	for {
 		if path == "" {
 			node.data = data
-			return
+			node.route = route
+			return nil
 		}
 
-		paramStart := strings.IndexByte(path, parameter)
-
-		if paramStart == -1 {
-			paramStart = strings.IndexByte(path, wildcard)
-		}
+		paramStart := strings.IndexAny(path, ":*")
 
 		// If it's a static route we are adding,
 		// just add the remainder as a normal node.
@@ -155,46 +153,57 @@ func (node *treeNode[T]) append(path string, data T) {
 			if node.prefix == "" {
 				node.prefix = path
 				node.data = data
-				node.addTrailingSlash(data)
-				return
+				node.route = route
+				return nil
 			}
 
 			child := &treeNode[T]{
 				prefix: path,
 				data:   data,
+				route:  route,
 			}
 
 			node.addChild(child)
-			child.addTrailingSlash(data)
-			return
+			return nil
 		}
 
 		// If we're directly in front of a parameter,
 		// add a parameter node.
 		if paramStart == 0 {
-			paramEnd := strings.IndexByte(path, separator)
-
-			if paramEnd == -1 {
-				paramEnd = len(path)
+			end := paramEnd(path)
+			name, constraint := splitParam(path[1:end])
+			match, err := newConstraint(constraint)
+			if err != nil {
+				return err
 			}
 
 			child := &treeNode[T]{
-				prefix: path[1:paramEnd],
-				kind:   path[paramStart],
+				prefix:     name,
+				kind:       path[paramStart],
+				constraint: constraint,
+				match:      match,
 			}
 
 			switch child.kind {
 			case parameter:
-				child.addTrailingSlash(data)
-				node.parameter = child
+				node.linkParameter(child)
 				node = child
-				path = path[paramEnd:]
+				path = path[end:]
 				continue
 
 			case wildcard:
+				if node.wildcard != nil {
+					if node.wildcard.prefix != child.prefix {
+						return errors.Errorf("wildcard '*%s' in route '%s' conflicts with '*%s' in existing route '%s'",
+							child.prefix, route, node.wildcard.prefix, node.wildcard.route)
+					}
+					return errors.Errorf("route '%s' conflicts with existing route '%s'", route, node.wildcard.route)
+				}
+
 				child.data = data
+				child.route = route
 				node.wildcard = child
-				return
+				return nil
 			}
 		}
 
@@ -213,12 +222,6 @@ func (node *treeNode[T]) append(path string, data T) {
 			prefix: path[:paramStart],
 		}
 
-		// Allow trailing slashes to return
-		// the same content as their parent node.
-		if child.prefix == "/" {
-			child.data = node.data
-		}
-
 		node.addChild(child)
 		node = child
 		path = path[paramStart:]
@@ -228,7 +231,7 @@ func (node *treeNode[T]) append(path string, data T) {
 // end is called when the node was fully parsed
 // and needs to decide the next control flow.
 // end is only called from `tree.Add`.
-func (node *treeNode[T]) end(path string, data T, i int, offset int) (*treeNode[T], int, flow) {
+func (node *treeNode[T]) end(path string, data T, i int, offset int, route string) (*treeNode[T], int, flow, error) {
 	char := path[i]
 
 	if char >= node.startIndex && char < node.endIndex {
@@ -237,25 +240,366 @@ func (node *treeNode[T]) end(path string, data T, i int, offset int) (*treeNode[
 		if index != 0 {
 			node = node.children[index]
 			offset = i
-			return node, offset, flowNext
+			return node, offset, flowNext, nil
 		}
 	}
 
 	// No fitting children found, does this node even contain a prefix yet?
 	// If no prefix is set, this is the starting node.
 	if node.prefix == "" {
-		node.append(path[i:], data)
-		return node, offset, flowStop
+		return node, offset, flowStop, node.append(path[i:], data, route)
 	}
 
 	// node: /user/|:id
 	// path: /user/|:id/profile
 	if node.parameter != nil && path[i] == parameter {
-		node = node.parameter
-		offset = i
-		return node, offset, flowBegin
+		name, constraint := splitParam(path[i+1 : i+paramEnd(path[i:])])
+
+		for p := node.parameter; p != nil; p = p.next {
+			if p.constraint != constraint {
+				continue
+			}
+
+			// Parameters at the same position must share their name
+			// unless they have different constraints.
+			// node: /user/|:id
+			// path: /user/|:name/x
+			if p.prefix != name {
+				return node, offset, flowStop, errors.Errorf("parameter '%s' in route '%s' conflicts with '%s' in existing route '%s'",
+					path[i:i+paramEnd(path[i:])], route, p.token(), p.anyRoute())
+			}
+
+			node = p
+			offset = i
+			return node, offset, flowBegin, nil
+		}
+	}
+
+	return node, offset, flowStop, node.append(path[i:], data, route)
+}
+
+// anyRoute returns a route registered at the node or below it.
+func (node *treeNode[T]) anyRoute() string {
+	if node.route != "" {
+		return node.route
+	}
+	for _, child := range node.children {
+		if child == nil {
+			continue
+		}
+		if route := child.anyRoute(); route != "" {
+			return route
+		}
+	}
+	for p := node.parameter; p != nil; p = p.next {
+		if route := p.anyRoute(); route != "" {
+			return route
+		}
+	}
+	if node.wildcard != nil {
+		return node.wildcard.route
+	}
+	return ""
+}
+
+// token returns the parameter as written in the route, e.g. :id<int>.
+func (node *treeNode[T]) token() string {
+	if node.constraint == "" {
+		return string(node.kind) + node.prefix
+	}
+	return string(node.kind) + node.prefix + string(constraintStart) + node.constraint + string(constraintEnd)
+}
+
+// hasSuffix reports whether a parameter node has children
+// other than the separator, i.e. static text within its segment.
+func (node *treeNode[T]) hasSuffix() bool {
+	return node.endIndex-node.startIndex > 1 || (node.startIndex != 0 && node.startIndex != separator)
+}
+
+// linkParameter adds a parameter node to the alternatives at this position,
+// the ones having a constraint are tried before the one without.
+func (node *treeNode[T]) linkParameter(child *treeNode[T]) {
+	var prev *treeNode[T]
+	link := &node.parameter
+	for *link != nil && (child.match == nil || (*link).match != nil) {
+		prev = *link
+		link = &prev.next
+	}
+	child.next = *link
+	*link = child
+
+	child.updateFallback()
+	if prev != nil {
+		prev.updateFallback()
+	}
+}
+
+// updateFallback sets whether a parameter node has an alternative,
+// a constraint or static text within its segment, findLinear
+// can't walk past it then.
+func (node *treeNode[T]) updateFallback() {
+	node.fallback = node.next != nil || node.match != nil || node.hasSuffix()
+}
+
+// findLinear is like find but walks the nodes in a loop as long as they offer
+// no alternative to fall back to, e.g. a static child next to a parameter or a constrained
+// parameter, from such a node on findChild is used. Plain parameters cost a call at most,
+// they're reported once the route is found like in findParameter.
+func (node *treeNode[T]) findLinear(path string, addParameter func(string, string)) *treeNode[T] {
+	for {
+		// node: /blog|
+		// path: /blog|/5
+		if len(path) < len(node.prefix) {
+			return nil
+		}
+		for i := 0; i < len(node.prefix); i++ {
+			if path[i] != node.prefix[i] {
+				return nil
+			}
+		}
+		path = path[len(node.prefix):]
+
+		if path == "" {
+			if node.route == "" {
+				return nil
+			}
+			return node
+		}
+
+		char := path[0]
+		if char >= node.startIndex && char < node.endIndex {
+			if index := node.indices[char-node.startIndex]; index != 0 {
+				if node.parameter != nil || node.wildcard != nil {
+					return node.findChild(path, addParameter)
+				}
+				node = node.children[index]
+				continue
+			}
+		}
+
+		// node: /|:id
+		// path: /|5/edit
+		if p := node.parameter; p != nil {
+			if p.fallback || node.wildcard != nil {
+				return node.findChild(path, addParameter)
+			}
+
+			end := 0
+			for end < len(path) && path[end] != separator {
+				end++
+			}
+			if end == 0 {
+				return nil
+			}
+			if end == len(path) {
+				if p.route == "" {
+					return nil
+				}
+				addParameter(p.prefix, path)
+				return p
+			}
+
+			// Without a suffix the only child of the parameter starts with the separator.
+			if p.startIndex != separator {
+				return nil
+			}
+			found := p.children[p.indices[0]].findLinear(path[end:], addParameter)
+			if found != nil {
+				addParameter(p.prefix, path[:end])
+			}
+			return found
+		}
+
+		// node: /|*any
+		// path: /|image.png
+		if node.wildcard != nil {
+			addParameter(node.wildcard.prefix, path)
+			return node.wildcard
+		}
+		return nil
+	}
+}
+
+// find returns the node of the route matching the given path below the node.
+// Static children are preferred over parameters and parameters over the wildcard,
+// when a branch doesn't lead to a route the next one is tried.
+func (node *treeNode[T]) find(path string, addParameter func(string, string)) *treeNode[T] {
+	// node: /blog|
+	// path: /blog|/5
+	if !strings.HasPrefix(path, node.prefix) {
+		return nil
+	}
+	return node.findChild(path[len(node.prefix):], addParameter)
+}
+
+// findChild returns the node of the route matching the path left after the node.
+func (node *treeNode[T]) findChild(path string, addParameter func(string, string)) *treeNode[T] {
+	if path == "" {
+		if node.route == "" {
+			return nil
+		}
+		return node
+	}
+
+	char := path[0]
+	if char >= node.startIndex && char < node.endIndex {
+		index := node.indices[char-node.startIndex]
+
+		if index != 0 {
+			if found := node.children[index].find(path, addParameter); found != nil {
+				return found
+			}
+		}
+	}
+
+	// node: /|:id
+	// path: /|blog
+	for p := node.parameter; p != nil; p = p.next {
+		if found := p.findParameter(path, addParameter); found != nil {
+			return found
+		}
+	}
+
+	// node: /|*any
+	// path: /|image.png
+	if node.wildcard != nil {
+		addParameter(node.wildcard.prefix, path)
+		return node.wildcard
 	}
 
-	node.append(path[i:], data)
-	return node, offset, flowStop
+	return nil
+}
+
+// findParameter matches the parameter node against the segment at the start of path.
+func (node *treeNode[T]) findParameter(path string, addParameter func(string, string)) *treeNode[T] {
+	end := strings.IndexByte(path, separator)
+	if end == -1 {
+		end = len(path)
+	}
+
+	// Static text following the parameter within the segment,
+	// the longest value is tried first.
+	// node: /img/:id|-thumb.png
+	// path: /img/5|-thumb.png
+	if node.hasSuffix() {
+		for i := end - 1; i > 0; i-- {
+			char := path[i]
+			if char < node.startIndex || char >= node.endIndex {
+				continue
+			}
+
+			index := node.indices[char-node.startIndex]
+			if index == 0 || (node.match != nil && !node.match(path[:i])) {
+				continue
+			}
+
+			if found := node.children[index].find(path[i:], addParameter); found != nil {
+				addParameter(node.prefix, path[:i])
+				return found
+			}
+		}
+	}
+
+	value := path[:end]
+	if value == "" || (node.match != nil && !node.match(value)) {
+		return nil
+	}
+
+	found := node.findChild(path[end:], addParameter)
+	if found != nil {
+		addParameter(node.prefix, value)
+	}
+	return found
+}
+
+// findFold is like find but compares static text case-insensitively,
+// the matched path is appended to buf with the case of the route.
+func (node *treeNode[T]) findFold(path string, buf []byte) ([]byte, bool) {
+	if len(path) < len(node.prefix) || !strings.EqualFold(path[:len(node.prefix)], node.prefix) {
+		return buf, false
+	}
+	return node.findChildFold(path[len(node.prefix):], append(buf, node.prefix...))
+}
+
+// findChildFold is like findChild but compares static text case-insensitively.
+func (node *treeNode[T]) findChildFold(path string, buf []byte) ([]byte, bool) {
+	if path == "" {
+		return buf, node.route != ""
+	}
+
+	n := len(buf)
+	for _, char := range foldChars(path[0]) {
+		if char < node.startIndex || char >= node.endIndex {
+			continue
+		}
+
+		index := node.indices[char-node.startIndex]
+		if index == 0 {
+			continue
+		}
+
+		if found, ok := node.children[index].findFold(path, buf[:n]); ok {
+			return found, true
+		}
+	}
+
+	for p := node.parameter; p != nil; p = p.next {
+		if found, ok := p.findParameterFold(path, buf[:n]); ok {
+			return found, true
+		}
+	}
+
+	if node.wildcard != nil {
+		return append(buf[:n], path...), true
+	}
+	return buf[:n], false
+}
+
+// findParameterFold is like findParameter but compares static text case-insensitively.
+func (node *treeNode[T]) findParameterFold(path string, buf []byte) ([]byte, bool) {
+	end := strings.IndexByte(path, separator)
+	if end == -1 {
+		end = len(path)
+	}
+
+	n := len(buf)
+	if node.hasSuffix() {
+		for i := end - 1; i > 0; i-- {
+			if node.match != nil && !node.match(path[:i]) {
+				continue
+			}
+
+			for _, char := range foldChars(path[i]) {
+				if char < node.startIndex || char >= node.endIndex {
+					continue
+				}
+
+				index := node.indices[char-node.startIndex]
+				if index == 0 {
+					continue
+				}
+
+				if found, ok := node.children[index].findFold(path[i:], append(buf[:n], path[:i]...)); ok {
+					return found, true
+				}
+			}
+		}
+	}
+
+	value := path[:end]
+	if value == "" || (node.match != nil && !node.match(value)) {
+		return buf[:n], false
+	}
+	return node.findChildFold(path[end:], append(buf[:n], value...))
+}
+
+// foldChars returns the character with its lower and upper case variants.
+func foldChars(char byte) []byte {
+	switch {
+	case 'a' <= char && char <= 'z':
+		return []byte{char, char - 'a' + 'A'}
+	case 'A' <= char && char <= 'Z':
+		return []byte{char, char - 'A' + 'a'}
+	}
+	return []byte{char}
 }
@@ -0,0 +1,178 @@
+package hblade
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// parameter constraints, e.g. /users/:id<int>,
+// and optional trailing parameters, e.g. /archive/:year/:month?
+const (
+	constraintStart = '<'
+	constraintEnd   = '>'
+	optional        = '?'
+)
+
+// constraints holds the predefined parameter constraints,
+// any other constraint is used as a regular expression.
+var constraints = map[string]func(string) bool{
+	"int":   isInt,
+	"uint":  isUint,
+	"alpha": isAlpha,
+	"alnum": isAlnum,
+	"uuid":  isUUID,
+}
+
+// paramEnd returns the length of the parameter or wildcard at the start of path
+// including its constraint and optional mark. The name consists of letters,
+// digits and underscores, so static text may follow it within the segment.
+func paramEnd(path string) int {
+	i := 1
+	for i < len(path) && isNameChar(path[i]) {
+		i++
+	}
+	if i < len(path) && path[i] == constraintStart {
+		end := strings.IndexByte(path[i:], constraintEnd)
+		if end == -1 {
+			return len(path)
+		}
+		i += end + 1
+	}
+	if i < len(path) && path[i] == optional {
+		i++
+	}
+	return i
+}
+
+// splitParam splits a parameter without its leading ':' into name and constraint.
+func splitParam(param string) (name string, constraint string) {
+	param = strings.TrimSuffix(param, string(optional))
+	start := strings.IndexByte(param, constraintStart)
+	if start == -1 {
+		return param, ""
+	}
+	return param[:start], strings.TrimSuffix(param[start+1:], string(constraintEnd))
+}
+
+// expandOptional returns the paths registered for a path with optional trailing parameters,
+// e.g. /archive/:year/:month? results in /archive/:year and /archive/:year/:month.
+// An optional wildcard also matches the path ending in its separator, e.g. /files/.
+func expandOptional(path string) []string {
+	if !strings.ContainsRune(path, optional) {
+		return []string{path}
+	}
+
+	var paths []string
+	segments := strings.Split(path, "/")
+	for i := range segments {
+		if !strings.HasSuffix(segments[i], string(optional)) {
+			continue
+		}
+		p := strings.Join(segments[:i], "/")
+		if p == "" {
+			p = "/"
+		}
+		paths = append(paths, p)
+		if segments[i][0] == wildcard && p != "/" {
+			paths = append(paths, p+"/")
+		}
+		segments[i] = strings.TrimSuffix(segments[i], string(optional))
+	}
+	return append(paths, strings.Join(segments, "/"))
+}
+
+// newConstraint returns the function checking parameter values for the given constraint.
+func newConstraint(constraint string) (func(string) bool, error) {
+	if constraint == "" {
+		return nil, nil
+	}
+	if match, ok := constraints[constraint]; ok {
+		return match, nil
+	}
+	re, err := regexp.Compile("^(?:" + constraint + ")$")
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid constraint '%s'", constraint)
+	}
+	return re.MatchString, nil
+}
+
+func isNameChar(c byte) bool {
+	return c == '_' || (c >= '0' && c <= '9') || (c|0x20 >= 'a' && c|0x20 <= 'z')
+}
+
+func isInt(s string) bool {
+	if len(s) > 1 && (s[0] == '-' || s[0] == '+') {
+		s = s[1:]
+	}
+	return isUint(s)
+}
+
+func isUint(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func isAlpha(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if c := s[i] | 0x20; c < 'a' || c > 'z' {
+			return false
+		}
+	}
+	return true
+}
+
+func isAlnum(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if c := s[i]; (c < '0' || c > '9') && (c|0x20 < 'a' || c|0x20 > 'z') {
+			return false
+		}
+	}
+	return true
+}
+
+func isUUID(s string) bool {
+	if len(s) != 36 {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		switch i {
+		case 8, 13, 18, 23:
+			if s[i] != '-' {
+				return false
+			}
+		default:
+			if c := s[i]; (c < '0' || c > '9') && (c|0x20 < 'a' || c|0x20 > 'f') {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// countParams returns the number of parameters and wildcards in the path.
+func countParams(path string) int {
+	n := 0
+	for {
+		start := strings.IndexAny(path, ":*")
+		if start == -1 {
+			return n
+		}
+		n++
+		path = path[start+paramEnd(path[start:]):]
+	}
+}
@@ -0,0 +1,352 @@
+package hblade
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestTreeAddConflicts(t *testing.T) {
+	tests := []struct {
+		existing []string
+		route    string
+		err      string
+	}{
+		{[]string{"/users"}, "/users", "route '/users' conflicts with existing route '/users'"},
+		{[]string{"/users/:id"}, "/users/:id", "route '/users/:id' conflicts with existing route '/users/:id'"},
+		{[]string{"/users/:id/x"}, "/users/:name/y", "parameter ':name' in route '/users/:name/y' conflicts with ':id' in existing route '/users/:id/x'"},
+		{[]string{"/files/*path"}, "/files/*name", "wildcard '*name' in route '/files/*name' conflicts with '*path' in existing route '/files/*path'"},
+		{[]string{"/files/*path"}, "/files/*path", "route '/files/*path' conflicts with existing route '/files/*path'"},
+		{[]string{"/o/:a?"}, "/o", "route '/o' conflicts with existing route '/o/:a?'"},
+		{[]string{"/o"}, "/o/:a?", "route '/o/:a?' conflicts with existing route '/o'"},
+		{[]string{"/o/:a?"}, "/o/:b/x", "parameter ':b' in route '/o/:b/x' conflicts with ':a' in existing route '/o/:a?'"},
+		{[]string{"/files/*path?"}, "/files/", "route '/files/' conflicts with existing route '/files/*path?'"},
+		{nil, "/users/:", "missing name for ':' in route '/users/:'"},
+		{nil, "/users/:id<int", "unclosed constraint in ':id<int' of route '/users/:id<int'"},
+		{nil, "/files/*path/x", "wildcard '*path' must be at the end of route '/files/*path/x'"},
+		{nil, "/files/*path<int>", "wildcard '*path<int>' can't have a constraint in route '/files/*path<int>'"},
+		{nil, "/users/:id:name", "parameter ':id' must be followed by static text in route '/users/:id:name'"},
+		{nil, "/users/x:id?", "optional ':id?' must be a whole segment in route '/users/x:id?'"},
+		{nil, "/users/:id?/x", "optional ':id?/x' must be followed by optional parameters only in route '/users/:id?/x'"},
+		{nil, "/users/:id<[>", "route '/users/:id<[>': invalid constraint '['"},
+	}
+
+	for _, test := range tests {
+		var tree Tree[string]
+		for _, route := range test.existing {
+			if err := tree.Add(route, route); err != nil {
+				t.Fatalf("Add(%q): %v", route, err)
+			}
+		}
+
+		err := tree.Add(test.route, test.route)
+		if err == nil {
+			t.Errorf("Add(%q) after %q: no error", test.route, test.existing)
+			continue
+		}
+		if msg := err.Error(); !strings.HasPrefix(msg, test.err) {
+			t.Errorf("Add(%q) after %q:\n got %s\nwant %s", test.route, test.existing, msg, test.err)
+		}
+	}
+}
+
+func TestTreeAddAllowed(t *testing.T) {
+	routes := []string{
+		"/users/:id",
+		"/users/:id/posts",
+		"/users/:id<int>/x",
+		"/users/:name<alpha>/x",
+		"/users/new",
+		"/files/*path",
+		"/files/static",
+	}
+
+	var tree Tree[string]
+	for _, route := range routes {
+		if err := tree.Add(route, route); err != nil {
+			t.Errorf("Add(%q): %v", route, err)
+		}
+	}
+}
+
+// lookupRoutes are the routes of TestTreeLookup.
+var lookupRoutes = []string{
+	"/",
+	"/users",
+	"/users/new",
+	"/users/:id<int>",
+	"/users/:name",
+	"/users/:name/posts/:post",
+	"/users/:name/edit",
+	"/users/new/edit",
+	"/img/:name.png",
+	"/img/:name-thumb.png",
+	"/files/readme",
+	"/files/*path",
+	"/blog/:slug/*rest",
+	"/docs/:page?",
+	"/shop/:cat/items",
+	"/shop/*any",
+}
+
+func TestTreeLookup(t *testing.T) {
+	var tree, reversed Tree[string]
+	for i, route := range lookupRoutes {
+		if err := tree.Add(route, route); err != nil {
+			t.Fatalf("Add(%q): %v", route, err)
+		}
+		// The lookup doesn't depend on the order of the routes.
+		route = lookupRoutes[len(lookupRoutes)-1-i]
+		if err := reversed.Add(route, route); err != nil {
+			t.Fatalf("Add(%q): %v", route, err)
+		}
+	}
+
+	tests := []struct {
+		path   string
+		route  string
+		params string
+	}{
+		// Static before parameter before wildcard.
+		{"/", "/", ""},
+		{"/users", "/users", ""},
+		{"/users/new", "/users/new", ""},
+		{"/users/5", "/users/:id<int>", "id=5"},
+		{"/users/bob", "/users/:name", "name=bob"},
+		{"/files/readme", "/files/readme", ""},
+		{"/files/a/b.txt", "/files/*path", "path=a/b.txt"},
+
+		// Backtracking from a static branch not leading to a route.
+		{"/users/new/edit", "/users/new/edit", ""},
+		{"/users/new/posts/1", "/users/:name/posts/:post", "name=new post=1"},
+		{"/users/5/edit", "/users/:name/edit", "name=5"},
+		{"/shop/toys/items", "/shop/:cat/items", "cat=toys"},
+		{"/shop/toys/other", "/shop/*any", "any=toys/other"},
+		{"/shop/toys", "/shop/*any", "any=toys"},
+
+		// Parameters within a segment, the longest value first.
+		{"/img/cat.png", "/img/:name.png", "name=cat"},
+		{"/img/cat-thumb.png", "/img/:name.png", "name=cat-thumb"},
+		{"/img/cat", "", ""},
+
+		// Wildcards and optional parameters.
+		{"/blog/hello/a/b", "/blog/:slug/*rest", "slug=hello rest=a/b"},
+		{"/docs", "/docs/:page?", ""},
+		{"/docs/intro", "/docs/:page?", "page=intro"},
+
+		// Misses.
+		{"/nope", "", ""},
+		{"/users/5/posts", "", ""},
+		{"/users/", "", ""},
+		{"", "", ""},
+	}
+
+	for _, test := range tests {
+		for _, tree := range []*Tree[string]{&tree, &reversed} {
+			var params []string
+			route := tree.Lookup(test.path, func(key, value string) {
+				params = append([]string{key + "=" + value}, params...)
+			})
+			if got := strings.Join(params, " "); route != test.route || got != test.params {
+				t.Errorf("Lookup(%q) = %q %q, want %q %q", test.path, route, got, test.route, test.params)
+			}
+		}
+	}
+}
+
+// benchRoutes is the number of resources of the benchmark router,
+// each one has a static and a parameterized route.
+const benchRoutes = 1000
+
+// newBenchRouter returns a router with a static and a parameterized GET route per resource.
+func newBenchRouter() *Router[int] {
+	router := NewRouter[int]()
+	for i := range benchRoutes {
+		n := strconv.Itoa(i)
+		router.Add("GET", "/api/v1/resource"+n, i)
+		router.Add("GET", "/api/v1/resource"+n+"/:id/items/:item", i)
+	}
+	router.Add("GET", "/u/:id", benchRoutes)
+	router.Add("GET", "/u/:id/posts/:post/comments", benchRoutes+1)
+	return router
+}
+
+// benchLookups are the paths looked up by the benchmarks.
+var benchLookups = []struct{ name, path string }{
+	{"static", "/api/v1/resource500"},
+	{"parameter", "/api/v1/resource500/42/items/7"},
+	{"short", "/u/5"},
+	{"nested", "/u/5/posts/7/comments"},
+	{"miss", "/api/v1/missing/42"},
+}
+
+func BenchmarkLookup(b *testing.B) {
+	router := newBenchRouter()
+	for _, lookup := range benchLookups {
+		b.Run(lookup.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for b.Loop() {
+				router.Lookup("GET", lookup.path, discardParameter)
+			}
+		})
+	}
+}
+
+// BenchmarkStaticMap compares the lookup checking the static map first
+// with walking the nodes only and the lookup before the map. The mixed
+// benchmark looks up all paths in turn to show the net effect.
+func BenchmarkStaticMap(b *testing.B) {
+	tree := &newBenchRouter().get
+	lookups := map[string]func(path string){
+		"map":      func(path string) { tree.lookup(path, discardParameter) },
+		"walk":     func(path string) { tree.find(path, discardParameter) },
+		"baseline": func(path string) { baselineLookup(tree, path, discardParameter) },
+	}
+
+	for _, lookup := range append(benchLookups, struct{ name, path string }{"mixed", ""}) {
+		for _, name := range []string{"map", "walk", "baseline"} {
+			find := lookups[name]
+			b.Run(lookup.name+"/"+name, func(b *testing.B) {
+				b.ReportAllocs()
+				if lookup.path != "" {
+					for b.Loop() {
+						find(lookup.path)
+					}
+					return
+				}
+				for b.Loop() {
+					for _, lookup := range benchLookups {
+						find(lookup.path)
+					}
+				}
+			})
+		}
+	}
+}
+
+// BenchmarkTreeLookup compares walking the nodes with the lookup
+// before constraints, which neither supported them nor backtracked.
+func BenchmarkTreeLookup(b *testing.B) {
+	tree := &newBenchRouter().get
+	for _, lookup := range benchLookups {
+		b.Run(lookup.name+"/baseline", func(b *testing.B) {
+			b.ReportAllocs()
+			for b.Loop() {
+				baselineLookup(tree, lookup.path, discardParameter)
+			}
+		})
+		b.Run(lookup.name+"/walk", func(b *testing.B) {
+			b.ReportAllocs()
+			for b.Loop() {
+				tree.find(lookup.path, discardParameter)
+			}
+		})
+	}
+}
+
+// baselineLookup is Tree.Lookup before constraints were added.
+func baselineLookup[T any](tree *Tree[T], path string, addParameter func(key string, value string)) T {
+	var (
+		i             uint
+		parameterPath string
+		wildcardPath  string
+		parameter     *treeNode[T]
+		wildcard      *treeNode[T]
+		node          = &tree.root
+	)
+
+	// Skip the first loop iteration if the starting characters are equal
+	if len(path) > 0 && len(node.prefix) > 0 && path[0] == node.prefix[0] {
+		i = 1
+	}
+
+begin:
+	// Search tree for equal parts until we can no longer proceed
+	for i < uint(len(path)) {
+		// The node we just checked is entirely included in our path.
+		// node: /|
+		// path: /|blog
+		if i == uint(len(node.prefix)) {
+			if node.wildcard != nil {
+				wildcard = node.wildcard
+				wildcardPath = path[i:]
+			}
+
+			parameter = node.parameter
+			parameterPath = path[i:]
+			char := path[i]
+
+			if char >= node.startIndex && char < node.endIndex {
+				index := node.indices[char-node.startIndex]
+
+				if index != 0 {
+					node = node.children[index]
+					path = path[i:]
+					i = 1
+					continue
+				}
+			}
+
+			// node: /|:id
+			// path: /|blog
+			if node.parameter != nil {
+				node = node.parameter
+				path = path[i:]
+				i = 1
+
+				for i < uint(len(path)) {
+					// node: /:id|/posts
+					// path: /123|/posts
+					if path[i] == separator {
+						addParameter(node.prefix, path[:i])
+						index := node.indices[separator-node.startIndex]
+						node = node.children[index]
+						path = path[i:]
+						i = 1
+						goto begin
+					}
+
+					i++
+				}
+
+				addParameter(node.prefix, path[:i])
+				return node.data
+			}
+
+			// node: /|*any
+			// path: /|image.png
+			goto notFound
+		}
+
+		// We got a conflict.
+		// node: /b|ag
+		// path: /b|riefcase
+		if path[i] != node.prefix[i] {
+			goto notFound
+		}
+
+		i++
+	}
+
+	// node: /blog|
+	// path: /blog|
+	if i == uint(len(node.prefix)) {
+		return node.data
+	}
+
+	// node: /|*any
+	// path: /|image.png
+notFound:
+	if parameter != nil {
+		addParameter(parameter.prefix, parameterPath)
+		return parameter.data
+	}
+
+	if wildcard != nil {
+		addParameter(wildcard.prefix, wildcardPath)
+		return wildcard.data
+	}
+
+	var empty T
+	return empty
+}
@@ -1,6 +1,10 @@
 package hblade
 
-import "net/http"
+import (
+	"net/http"
+	"reflect"
+	"runtime"
+)
 
 type H map[string]any
 
@@ -17,3 +21,8 @@ func filterFlags(content string) string {
 func hasRequestBody(method string) bool {
 	return method == http.MethodPost || method == http.MethodPut || method == http.MethodPatch
 }
+
+// nameOfFunction returns the name of the given function.
+func nameOfFunction(f any) string {
+	return runtime.FuncForPC(reflect.ValueOf(f).Pointer()).Name()
+}
@@ -10,6 +10,8 @@ const (
 	cacheControlHeader            = "Cache-Control"
 	cacheControlAlwaysValidate    = "must-revalidate"
 	cacheControlMedia             = "public, max-age=13824000"
+	cacheControlNoCache           = "no-cache"
+	cacheControlImmutable         = "public, max-age=31536000, immutable"
 	contentTypeOptionsHeader      = "X-Content-Type-Options"
 	contentTypeOptions            = "nosniff"
 	xssProtectionHeader           = "X-XSS-Protection"
@@ -20,14 +22,28 @@ const (
 	contentTypeCSS                = "text/css; charset=utf-8"
 	contentTypeJavaScript         = "text/javascript; charset=utf-8"
 	contentTypeJSON               = "application/json; charset=utf-8"
+	contentTypeXML                = "application/xml; charset=utf-8"
+	contentTypeYAML               = "application/yaml; charset=utf-8"
+	contentTypeTOML               = "application/toml; charset=utf-8"
 	contentTypePlainText          = "text/plain; charset=utf-8"
 	contentTypeEventStream        = "text/event-stream; charset=utf-8"
 	contentTypeSVG                = "image/svg+xml"
 	contentEncodingHeader         = "Content-Encoding"
 	contentEncodingGzip           = "gzip"
+	contentEncodingBrotli         = "br"
+	contentEncodingDeflate        = "deflate"
+	contentEncodingZstd           = "zstd"
+	acceptHeader                  = "Accept"
 	acceptEncodingHeader          = "Accept-Encoding"
+	rangeHeader                   = "Range"
+	upgradeHeader                 = "Upgrade"
+	allowHeader                   = "Allow"
+	varyHeader                    = "Vary"
 	contentLengthHeader           = "Content-Length"
+	lastEventIDHeader             = "Last-Event-ID"
 	ifNoneMatchHeader             = "If-None-Match"
+	ifModifiedSinceHeader         = "If-Modified-Since"
+	lastModifiedHeader            = "Last-Modified"
 	referrerPolicyHeader          = "Referrer-Policy"
 	referrerPolicySameOrigin      = "no-referrer"
 	strictTransportSecurityHeader = "Strict-Transport-Security"